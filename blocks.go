@@ -0,0 +1,132 @@
+package fcqs
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/yendo/fcqs/internal/value"
+)
+
+// BlockOptions represents options to choose a fenced code block in the note.
+type BlockOptions struct {
+	// Index is the 1-based index of the block among the blocks in the language.
+	Index int
+
+	// Lang is the language identifier of the blocks. All blocks are chosen if it is empty.
+	Lang string
+}
+
+// codeBlock represents a fenced code block in the contents of a note.
+type codeBlock struct {
+	opening *value.FenceLine
+	lines   []string
+}
+
+// hasLang reports whether the block is in the language ignoring cases.
+// Any block is in the empty language.
+func (b codeBlock) hasLang(lang string) bool {
+	return lang == "" || strings.EqualFold(b.opening.Lang(), lang)
+}
+
+// code returns the lines of the block joined with line feeds.
+func (b codeBlock) code() string {
+	return strings.Join(b.lines, "\n")
+}
+
+// summary returns the language identifier and the first non-blank line of the block.
+func (b codeBlock) summary() string {
+	var first string
+	for _, line := range b.lines {
+		if first = strings.TrimSpace(line); first != "" {
+			break
+		}
+	}
+
+	if lang := b.opening.Lang(); lang != "" {
+		return "[" + lang + "] " + first
+	}
+	return first
+}
+
+// WriteBlock writes the code of the fenced code block chosen by the options in the contents of the note.
+// Nothing is written if there is no such block.
+func WriteBlock(w io.Writer, r io.Reader, ref value.NoteRef, opts BlockOptions) error {
+	blocks, err := seekBlocks(r, ref, opts.Lang)
+	if err != nil {
+		return err
+	}
+
+	if opts.Index < 1 || opts.Index > len(blocks) {
+		return nil
+	}
+
+	for _, line := range blocks[opts.Index-1].lines {
+		fmt.Fprintln(w, line)
+	}
+
+	return nil
+}
+
+// WriteBlocks writes the indexes and the summaries of the fenced code blocks in the language in the contents of the note.
+func WriteBlocks(w io.Writer, r io.Reader, ref value.NoteRef, opts BlockOptions) error {
+	blocks, err := seekBlocks(r, ref, opts.Lang)
+	if err != nil {
+		return err
+	}
+
+	for i, b := range blocks {
+		fmt.Fprintf(w, "%d: %s\n", i+1, b.summary())
+	}
+
+	return nil
+}
+
+// seekBlocks returns the fenced code blocks in the language in the contents of the note.
+func seekBlocks(r io.Reader, ref value.NoteRef, lang string) ([]codeBlock, error) {
+	var buf bytes.Buffer
+	if _, err := writeContents(&buf, r, ref, false, false); err != nil {
+		return nil, err
+	}
+
+	return parseBlocks(&buf, lang)
+}
+
+// parseBlocks returns the fenced code blocks in the language in the contents.
+func parseBlocks(r io.Reader, lang string) ([]codeBlock, error) {
+	var blocks []codeBlock
+	var block *codeBlock
+
+	scanner := newScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		fenceLine, isFenceLine := value.NewFenceLine(line)
+
+		switch {
+		case block == nil:
+			if isFenceLine {
+				block = &codeBlock{opening: fenceLine}
+			}
+
+		case isFenceLine && fenceLine.Closes(block.opening):
+			if block.hasLang(lang) {
+				blocks = append(blocks, *block)
+			}
+			block = nil
+
+		default:
+			block.lines = append(block.lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("seek blocks: %w", err)
+	}
+
+	// An unterminated fenced code block is closed at the end of the note.
+	if block != nil && block.hasLang(lang) {
+		blocks = append(blocks, *block)
+	}
+
+	return blocks, nil
+}
@@ -1,14 +1,22 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	flag "github.com/spf13/pflag"
 	"github.com/yendo/fcqs"
+	"github.com/yendo/fcqs/internal/clipboard"
+	"github.com/yendo/fcqs/internal/render"
 	"github.com/yendo/fcqs/internal/value"
+	"golang.org/x/term"
 )
 
 var (
@@ -16,14 +24,70 @@ var (
 
 	showVersion = flag.BoolP("version", "v", false, "output the version")
 	showURL     = flag.BoolP("url", "u", false, "output the first URL from the note")
+	showURLs    = flag.BoolP("urls", "", false, "output all URLs with their labels from the note")
+	urlMatch    = flag.StringP("url-match", "", "", "output only URLs whose scheme, domain or label matches the pattern instead of FCQS_URL_MATCH")
+	relaxedURLs = flag.BoolP("relaxed-urls", "", false, "find URLs without schemes like github.com/foo")
 	showCmd     = flag.BoolP("command", "c", false, "output the first command from the note")
+	blockIndex  = flag.IntP("block", "", 0, "output the code of the N-th fenced code block from the note")
+	showBlocks  = flag.BoolP("blocks", "", false, "output the indexes and the first lines of the fenced code blocks from the note")
+	blockLang   = flag.StringP("lang", "", "", "choose only fenced code blocks in the language for --block and --blocks")
 	showLoc     = flag.BoolP("location", "l", false, "output the note location")
+	showMeta    = flag.BoolP("metadata", "m", false, "output the note metadata")
+	withMeta    = flag.BoolP("with-metadata", "", false, "output the contents with the metadata comment below the title")
+	showLinks   = flag.BoolP("links", "", false, "output the titles of the notes linked from the note")
+	showBack    = flag.BoolP("backlinks", "", false, "output the titles of the notes that link to the note")
 	showBash    = flag.BoolP("bash", "", false, "output bash integration script")
 	noTitle     = flag.BoolP("notitle", "t", false, "no title on output content")
+	copyOutput  = flag.BoolP("copy", "", false, "copy the output to the clipboard instead of standard output")
+	rendered    = flag.BoolP("render", "", false, "output the note rendered for terminals")
+	withAliases = flag.BoolP("aliases", "a", false, "output the aliases with the titles")
+	separate    = flag.BoolP("separate", "s", false, "output duplicate titles separately with their locations")
+	format      = flag.StringP("format", "", textFormat, "output format of the titles and metadata: text or json")
+	noteID      = flag.StringP("id", "", "", "find the note by the ID instead of the title")
+	runCmd      = flag.BoolP("run", "", false, "run the first command from the note after confirmation")
+	assumeYes   = flag.BoolP("yes", "y", false, "run the command without confirmation unless it is dangerous")
+	checkCmd    = flag.BoolP("check", "", false, "output warnings for dangerous commands in the first command from the note")
+	lintSecrets = flag.BoolP("secrets", "", false, "output the locations of possible secrets in the notes files for lint")
+	resolveRefs = flag.BoolP("resolve-secrets", "", false, "resolve secret references like ${env:NAME} and ${cmd:command} in the command")
+	expandRefs  = flag.BoolP("expand-secrets", "", false, "replace secret references with shell expansions like \"${NAME}\" and \"$(command)\" in the command")
+	joinSep     = flag.StringP("join", "", "", "join the command lines into one line with the separator: && or ;")
+	quoteCmd    = flag.BoolP("quote", "", false, "output the multi-line command as one line like eval $'...' to paste it intact")
 
 	ErrInvalidNumberOfArgs = errors.New("invalid number of arguments")
+	ErrInvalidFormat       = errors.New("invalid format")
+	ErrInvalidJoin         = errors.New("invalid separator to join")
+	ErrNoCmdLine           = errors.New("no command-line block in the note")
+	ErrLintWithOutputFlag  = errors.New("flags to output the note cannot be used with lint")
+	ErrCanceled            = errors.New("canceled")
+
+	// stdin is the input for the confirmation and the command to run.
+	stdin io.Reader = os.Stdin
+
+	// stderr is the output for warnings and the command to run.
+	stderr io.Writer = os.Stderr
+)
+
+const (
+	textFormat = "text"
+	jsonFormat = "json"
+
+	// dangerExitStatus is the exit status of --check for dangerous commands,
+	// which is distinct from the exit status 1 for the other errors.
+	dangerExitStatus = 2
+
+	// lintCommand is the subcommand like "fcqs-cli lint --secrets" to check the notes files.
+	lintCommand = "lint"
 )
 
+// exitStatusError represents the non-zero exit status of the command run from the note.
+type exitStatusError struct {
+	status int
+}
+
+func (e *exitStatusError) Error() string {
+	return fmt.Sprintf("exit status %d", e.status)
+}
+
 func run(w io.Writer) error {
 	flag.Parse()
 	args := flag.Args()
@@ -38,46 +102,314 @@ func run(w io.Writer) error {
 		return nil
 	}
 
+	if *format != textFormat && *format != jsonFormat {
+		return ErrInvalidFormat
+	}
+
+	if *joinSep != "" && *joinSep != "&&" && *joinSep != ";" {
+		return ErrInvalidJoin
+	}
+
+	policy, err := value.ParseMatchPolicy(os.Getenv("FCQS_TITLE_MATCH"))
+	if err != nil {
+		return err
+	}
+
 	notes, err := fcqs.OpenNotesFiles()
 	if err != nil {
 		return err
 	}
 	defer notes.Close()
 
-	switch len(args) {
-	case 0:
-		if *showURL || *showCmd || *showLoc {
+	// Without a lint flag, "lint" is the title of a note.
+	if len(args) == 1 && args[0] == lintCommand && *lintSecrets {
+		if hasOutputFlag() || *rendered || *noteID != "" {
+			return ErrLintWithOutputFlag
+		}
+		return lint(w, notes)
+	}
+
+	handle := outputNote
+	if *runCmd {
+		handle = runNote
+	}
+
+	switch {
+	case len(args) == 0 && *noteID == "":
+		if hasOutputFlag() || *lintSecrets {
 			return ErrInvalidNumberOfArgs
 		}
-		return fcqs.WriteTitles(w, notes.Reader)
-	case 1:
+		opts := fcqs.ListOptions{Aliases: *withAliases, Separate: *separate, MatchPolicy: policy}
+		if *format == jsonFormat {
+			return fcqs.WriteTitlesJSON(w, notes.Reader, opts)
+		}
+		return fcqs.WriteTitlesWithOptions(w, notes.Reader, opts)
+	case len(args) == 0:
+		id, err := value.NewID(*noteID)
+		if err != nil {
+			return err
+		}
+		return handle(w, notes, id)
+	case len(args) == 1 && *noteID == "":
 		title, err := value.NewTitle(args[0])
 		if err != nil {
 			// This error should be ignored to omit argument checking in shell scripts.
 			return nil
 		}
+		return handle(w, notes, title.WithMatchPolicy(policy))
+	default:
+		return ErrInvalidNumberOfArgs
+	}
+}
+
+// hasOutputFlag reports whether any flag to choose the output of the note is set.
+func hasOutputFlag() bool {
+	return *showURL || *showURLs || *showCmd || *blockIndex != 0 || *showBlocks || *blockLang != "" || *showLoc || *showMeta ||
+		*withMeta || *showLinks || *showBack || *copyOutput || *checkCmd || *runCmd
+}
+
+// outputNote writes the note with the title to the writer or the clipboard.
+func outputNote(w io.Writer, notes *fcqs.NotesFiles, ref value.NoteRef) error {
+	if !*copyOutput {
+		return writeNote(w, notes, ref)
+	}
+
+	var buf bytes.Buffer
+	if err := writeNote(&buf, notes, ref); err != nil {
+		return err
+	}
+
+	for _, secret := range fcqs.FindSecrets(buf.String()) {
+		fmt.Fprintf(stderr, "warning: line %d of copied text: possible secret: %s\n", secret.Line, secret.Kind)
+	}
+
+	return clipboard.Write(buf.Bytes())
+}
+
+// lint writes the possible secrets in the notes files.
+// It fails with the exit status 1 if there are possible secrets.
+func lint(w io.Writer, notes *fcqs.NotesFiles) error {
+	n, err := fcqs.LintSecrets(w, notes.Reader)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return &exitStatusError{status: 1}
+	}
+
+	return nil
+}
+
+// runNote runs the first command-line block of the note after confirmation,
+// and records the exit status in the history.
+func runNote(w io.Writer, notes *fcqs.NotesFiles, ref value.NoteRef) error {
+	cl, err := fcqs.SeekCmdLine(notes.Reader, ref)
+	if err != nil {
+		return err
+	}
+	if cl == nil {
+		return ErrNoCmdLine
+	}
+
+	rules, err := fcqs.LoadDangerRules()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(w, cl.Code)
+	if cl.Dir != "" {
+		fmt.Fprintf(w, "workdir: %s\n", cl.Dir)
+	}
+	for _, env := range cl.Env {
+		fmt.Fprintf(w, "env: %s\n", env)
+	}
+
+	// Dangerous commands always need confirmation.
+	question := "Run the command?"
+	warnings := rules.Check(cl.Code)
+	for _, dw := range warnings {
+		fmt.Fprintf(w, "warning: %s\n", dw)
+	}
+	if len(warnings) > 0 {
+		question = "Run the dangerous command?"
+	}
+
+	// The input after the answer is kept for the command.
+	in := bufio.NewReader(stdin)
+	if (!*assumeYes || len(warnings) > 0) && !confirm(w, in, question) {
+		return ErrCanceled
+	}
+
+	// The secret references are resolved after confirmation not to show the secrets.
+	if *resolveRefs {
+		if cl.Code, err = fcqs.ResolveSecrets(cl.Code); err != nil {
+			return err
+		}
+	}
+
+	// The terminal is passed to the command as it is for interactive commands
+	// unless the input has been read ahead.
+	cmdStdin := stdin
+	if in.Buffered() > 0 {
+		cmdStdin = in
+	}
+
+	status, err := cl.Run(cmdStdin, w, stderr)
+	if err != nil {
+		return err
+	}
+
+	entry := fcqs.HistoryEntry{Time: time.Now(), ID: cl.ID, Title: cl.Title, Dir: cl.Dir, ExitStatus: status}
+	if err := fcqs.AppendHistory(entry); err != nil {
+		return err
+	}
+
+	if status != 0 {
+		return &exitStatusError{status: status}
+	}
+	return nil
+}
+
+// checkNote writes the warnings for the dangerous commands in the first command-line block of the note.
+// It fails with dangerExitStatus if there are dangerous commands, so that shell scripts confirm them.
+func checkNote(w io.Writer, notes *fcqs.NotesFiles, ref value.NoteRef) error {
+	rules, err := fcqs.LoadDangerRules()
+	if err != nil {
+		return err
+	}
+
+	cl, err := fcqs.SeekCmdLine(notes.Reader, ref)
+	if err != nil || cl == nil {
+		return err
+	}
+
+	warnings := rules.Check(cl.Code)
+	for _, dw := range warnings {
+		fmt.Fprintf(w, "warning: %s\n", dw)
+	}
+	if len(warnings) > 0 {
+		return &exitStatusError{status: dangerExitStatus}
+	}
+
+	return nil
+}
 
-		switch {
-		case *showURL:
-			return fcqs.WriteFirstURL(w, notes.Reader, title)
-		case *showCmd:
-			return fcqs.WriteFirstCmdLineBlock(w, notes.Reader, title)
-		case *showLoc:
-			return fcqs.WriteNoteLocation(w, notes.Files, title)
-		default:
-			return fcqs.WriteContents(w, notes.Reader, title, *noTitle)
+// confirm asks the question and reports whether the answer is yes.
+func confirm(w io.Writer, r *bufio.Reader, question string) bool {
+	fmt.Fprintf(w, "%s [y/N] ", question)
+
+	answer, _ := r.ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	default:
+		return false
+	}
+}
+
+// writeNote writes the note with the title in the way specified by the flags.
+func writeNote(w io.Writer, notes *fcqs.NotesFiles, ref value.NoteRef) error {
+	switch {
+	case *showURL:
+		return fcqs.WriteFirstURLWithOptions(w, notes.Reader, ref, urlOptions())
+	case *showURLs:
+		return fcqs.WriteURLsWithOptions(w, notes.Reader, ref, urlOptions())
+	case *showCmd:
+		return fcqs.WriteFirstCmdLineBlockWithOptions(w, notes.Reader, ref, cmdLineOptions())
+	case *showBlocks:
+		return fcqs.WriteBlocks(w, notes.Reader, ref, blockOptions())
+	case *blockIndex != 0 || *blockLang != "":
+		return fcqs.WriteBlock(w, notes.Reader, ref, blockOptions())
+	case *showLoc:
+		return fcqs.WriteNoteLocation(w, notes.Files, ref)
+	case *showLinks:
+		return fcqs.WriteLinks(w, notes.Reader, ref)
+	case *showBack:
+		return fcqs.WriteBacklinks(w, notes.Reader, ref)
+	case *showMeta:
+		if *format == jsonFormat {
+			return fcqs.WriteMetadataJSON(w, notes.Reader, ref)
+		}
+		return fcqs.WriteMetadata(w, notes.Reader, ref)
+	case *checkCmd:
+		return checkNote(w, notes, ref)
+	case *rendered:
+		// The preview is rendered without the warnings if the danger rules are broken.
+		rules, err := fcqs.LoadDangerRules()
+		if err != nil {
+			fmt.Fprintf(stderr, "warning: %s\n", err)
 		}
+		return fcqs.WriteRenderedContents(w, notes.Reader, ref, *noTitle, renderOptions(), rules)
+	case *withMeta:
+		return fcqs.WriteContentsWithMetadata(w, notes.Reader, ref, *noTitle)
 	default:
-		return ErrInvalidNumberOfArgs
+		return fcqs.WriteContents(w, notes.Reader, ref, *noTitle)
+	}
+}
+
+// cmdLineOptions returns the options to write the command-line block of the note.
+func cmdLineOptions() fcqs.CmdLineOptions {
+	return fcqs.CmdLineOptions{ResolveSecrets: *resolveRefs, ExpandSecrets: *expandRefs, Join: *joinSep, Quote: *quoteCmd}
+}
+
+// urlOptions returns the options to find URLs in the note.
+// The pattern is FCQS_URL_MATCH without --url-match, so that shell scripts need not quote it in commands.
+func urlOptions() fcqs.URLOptions {
+	match := *urlMatch
+	if match == "" {
+		match = os.Getenv("FCQS_URL_MATCH")
+	}
+
+	return fcqs.URLOptions{Match: match, Relaxed: *relaxedURLs}
+}
+
+// blockOptions returns the options to choose fenced code blocks in the note.
+// The first block in the language is chosen without the index.
+func blockOptions() fcqs.BlockOptions {
+	index := *blockIndex
+	if index == 0 {
+		index = 1
+	}
+
+	return fcqs.BlockOptions{Index: index, Lang: *blockLang}
+}
+
+// renderOptions returns the options to render the note for the terminal.
+func renderOptions() render.Options {
+	return render.Options{
+		Color: os.Getenv("NO_COLOR") == "",
+		Width: terminalWidth(),
 	}
 }
 
+// terminalWidth returns the width of the fzf preview window or the terminal.
+func terminalWidth() int {
+	for _, env := range []string{"FZF_PREVIEW_COLUMNS", "COLUMNS"} {
+		if w, err := strconv.Atoi(os.Getenv(env)); err == nil && w > 0 {
+			return w
+		}
+	}
+
+	if w, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && w > 0 {
+		return w
+	}
+
+	return render.DefaultWidth
+}
+
 func main() {
 	exitCode := 0
 
 	if err := run(os.Stdout); err != nil {
 		exitCode = 1
-		fmt.Fprintln(os.Stderr, err)
+
+		// The exit status of the command run from the note is passed through.
+		var statusErr *exitStatusError
+		if errors.As(err, &statusErr) {
+			exitCode = statusErr.status
+		} else {
+			fmt.Fprintln(os.Stderr, err)
+		}
 	}
 
 	os.Exit(exitCode)
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	flag "github.com/spf13/pflag"
@@ -25,6 +27,19 @@ func setCommandLineFlag(t *testing.T, f string) {
 	})
 }
 
+func setCommandLineStringFlag(t *testing.T, f, value string) {
+	t.Helper()
+
+	defaultValue := flag.CommandLine.Lookup(f).DefValue
+	err := flag.CommandLine.Set(f, value)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		err := flag.CommandLine.Set(f, defaultValue)
+		require.NoError(t, err)
+	})
+}
+
 func setOSArgs(t *testing.T, args []string) {
 	t.Helper()
 
@@ -36,6 +51,31 @@ func setOSArgs(t *testing.T, args []string) {
 	})
 }
 
+func setStdin(t *testing.T, input string) {
+	t.Helper()
+
+	oldStdin := stdin
+	stdin = strings.NewReader(input)
+
+	t.Cleanup(func() {
+		stdin = oldStdin
+	})
+}
+
+func setStderr(t *testing.T) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	oldStderr := stderr
+	stderr = &buf
+
+	t.Cleanup(func() {
+		stderr = oldStderr
+	})
+
+	return &buf
+}
+
 func TestRunSuccess(t *testing.T) {
 	t.Setenv("FCQS_NOTES_FILE", test.NotesFile)
 	t.Setenv("FCQS_NOTES_FILES", "")
@@ -71,6 +111,17 @@ func TestRunSuccess(t *testing.T) {
 		assert.Equal(t, "contents\n", buf.String())
 	})
 
+	t.Run("with an arg and title match policy", func(t *testing.T) {
+		t.Setenv("FCQS_TITLE_MATCH", "case")
+		setOSArgs(t, []string{"fcqs-cli", "TITLE"})
+
+		var buf bytes.Buffer
+		err := run(&buf)
+
+		require.NoError(t, err)
+		assert.Equal(t, "# title\n\ncontents\n", buf.String())
+	})
+
 	t.Run("with an empty arg and some option", func(t *testing.T) {
 		setOSArgs(t, []string{"fcqs-cli", "-u", ""})
 		setCommandLineFlag(t, "url")
@@ -110,6 +161,19 @@ func TestRunFail(t *testing.T) {
 		assert.Empty(t, buf.String())
 	})
 
+	t.Run("invalid title match policy", func(t *testing.T) {
+		t.Setenv("FCQS_NOTES_FILE", test.NotesFile)
+		t.Setenv("FCQS_NOTES_FILES", "")
+		t.Setenv("FCQS_TITLE_MATCH", "other")
+
+		var buf bytes.Buffer
+		err := run(&buf)
+
+		require.Error(t, err)
+		require.EqualError(t, err, "invalid match policy: other")
+		assert.Empty(t, buf.String())
+	})
+
 	t.Run("with two args", func(t *testing.T) {
 		t.Setenv("FCQS_NOTES_FILE", test.NotesFile)
 		t.Setenv("FCQS_NOTES_FILES", "")
@@ -199,6 +263,486 @@ func TestRunWithURLFlag(t *testing.T) {
 	})
 }
 
+func TestRunWithURLsFlag(t *testing.T) {
+	t.Setenv("FCQS_NOTES_FILE", test.NotesFile)
+	t.Setenv("FCQS_NOTES_FILES", "")
+	setCommandLineFlag(t, "urls")
+
+	t.Run("with no args", func(t *testing.T) {
+		setOSArgs(t, []string{"fcqs-cli", "--urls"})
+
+		var buf bytes.Buffer
+		err := run(&buf)
+
+		require.EqualError(t, err, "invalid number of arguments")
+		assert.Empty(t, buf.String())
+	})
+
+	t.Run("with a arg", func(t *testing.T) {
+		setOSArgs(t, []string{"fcqs-cli", "--urls", "URL"})
+
+		var buf bytes.Buffer
+		err := run(&buf)
+
+		require.NoError(t, err)
+		assert.Equal(t, "fcqs: http://github.com/yendo/fcqs/\ngithub: http://github.com/\n", buf.String())
+	})
+}
+
+func TestRunWithURLMatchFlag(t *testing.T) {
+	t.Setenv("FCQS_NOTES_FILE", test.URLsFile)
+	t.Setenv("FCQS_NOTES_FILES", "")
+	setCommandLineFlag(t, "urls")
+
+	t.Run("with a pattern", func(t *testing.T) {
+		setCommandLineStringFlag(t, "url-match", "go.dev")
+		setOSArgs(t, []string{"fcqs-cli", "--urls", "--url-match", "go.dev", "links"})
+
+		var buf bytes.Buffer
+		err := run(&buf)
+
+		require.NoError(t, err)
+		assert.Equal(t, "the Go docs: https://go.dev/doc/\nhttps://pkg.go.dev/\n", buf.String())
+	})
+
+	t.Run("with relaxed URLs", func(t *testing.T) {
+		setCommandLineFlag(t, "relaxed-urls")
+		setCommandLineStringFlag(t, "url-match", "github.com")
+		setOSArgs(t, []string{"fcqs-cli", "--urls", "--relaxed-urls", "--url-match", "github.com", "relaxed links"})
+
+		var buf bytes.Buffer
+		err := run(&buf)
+
+		require.NoError(t, err)
+		assert.Equal(t, "repository: https://github.com/yendo/fcqs\n", buf.String())
+	})
+	t.Run("with the environment variable", func(t *testing.T) {
+		t.Setenv("FCQS_URL_MATCH", "go.dev")
+		setOSArgs(t, []string{"fcqs-cli", "--urls", "links"})
+
+		var buf bytes.Buffer
+		err := run(&buf)
+
+		require.NoError(t, err)
+		assert.Equal(t, "the Go docs: https://go.dev/doc/\nhttps://pkg.go.dev/\n", buf.String())
+	})
+
+	t.Run("flag over the environment variable", func(t *testing.T) {
+		t.Setenv("FCQS_URL_MATCH", "go.dev")
+		setCommandLineStringFlag(t, "url-match", "pkg.go.dev")
+		setOSArgs(t, []string{"fcqs-cli", "--urls", "--url-match", "pkg.go.dev", "links"})
+
+		var buf bytes.Buffer
+		err := run(&buf)
+
+		require.NoError(t, err)
+		assert.Equal(t, "https://pkg.go.dev/\n", buf.String())
+	})
+}
+
+func TestRunWithBlockFlags(t *testing.T) {
+	t.Setenv("FCQS_NOTES_FILE", test.BlocksFile)
+	t.Setenv("FCQS_NOTES_FILES", "")
+
+	t.Run("with no args", func(t *testing.T) {
+		setCommandLineStringFlag(t, "block", "1")
+		setOSArgs(t, []string{"fcqs-cli", "--block", "1"})
+
+		var buf bytes.Buffer
+		err := run(&buf)
+
+		require.EqualError(t, err, "invalid number of arguments")
+		assert.Empty(t, buf.String())
+	})
+
+	t.Run("with an index", func(t *testing.T) {
+		setCommandLineStringFlag(t, "block", "2")
+		setOSArgs(t, []string{"fcqs-cli", "--block", "2", "deployment"})
+
+		var buf bytes.Buffer
+		err := run(&buf)
+
+		require.NoError(t, err)
+		assert.Equal(t, "apiVersion: apps/v1\nkind: Deployment\n", buf.String())
+	})
+
+	t.Run("with a language", func(t *testing.T) {
+		setCommandLineStringFlag(t, "lang", "sh")
+		setOSArgs(t, []string{"fcqs-cli", "--lang", "sh", "deployment"})
+
+		var buf bytes.Buffer
+		err := run(&buf)
+
+		require.NoError(t, err)
+		assert.Equal(t, "kubectl apply -f deployment.yaml\n", buf.String())
+	})
+
+	t.Run("list blocks", func(t *testing.T) {
+		setCommandLineFlag(t, "blocks")
+		setCommandLineStringFlag(t, "lang", "yaml")
+		setOSArgs(t, []string{"fcqs-cli", "--blocks", "--lang", "yaml", "deployment"})
+
+		var buf bytes.Buffer
+		err := run(&buf)
+
+		require.NoError(t, err)
+		assert.Equal(t, "1: [yaml] apiVersion: apps/v1\n2: [YAML] replicas: 3\n", buf.String())
+	})
+}
+
+func TestRunWithCopyFlag(t *testing.T) {
+	t.Setenv("FCQS_NOTES_FILE", test.BlocksFile)
+	t.Setenv("FCQS_NOTES_FILES", "")
+	setCommandLineFlag(t, "copy")
+
+	t.Run("with no args", func(t *testing.T) {
+		setOSArgs(t, []string{"fcqs-cli", "--copy"})
+
+		var buf bytes.Buffer
+		err := run(&buf)
+
+		require.EqualError(t, err, "invalid number of arguments")
+		assert.Empty(t, buf.String())
+	})
+
+	t.Run("with a arg", func(t *testing.T) {
+		// A fake pbcopy command writes the clipboard to the file.
+		dir := t.TempDir()
+		out := filepath.Join(dir, "clipboard")
+		script := fmt.Sprintf("#!/bin/sh\nPATH=%q\ncat > %q\n", os.Getenv("PATH"), out)
+		err := os.WriteFile(filepath.Join(dir, "pbcopy"), []byte(script), 0o755)
+		require.NoError(t, err)
+		t.Setenv("PATH", dir)
+		t.Setenv("WAYLAND_DISPLAY", "")
+		t.Setenv("DISPLAY", "")
+
+		setCommandLineStringFlag(t, "lang", "sh")
+		setOSArgs(t, []string{"fcqs-cli", "--copy", "--lang", "sh", "deployment"})
+
+		var buf bytes.Buffer
+		err = run(&buf)
+
+		require.NoError(t, err)
+		assert.Empty(t, buf.String())
+
+		data, err := os.ReadFile(out)
+		require.NoError(t, err)
+		assert.Equal(t, "kubectl apply -f deployment.yaml\n", string(data))
+	})
+
+	t.Run("with secrets", func(t *testing.T) {
+		t.Setenv("FCQS_NOTES_FILE", test.SecretsFile)
+		dir := t.TempDir()
+		err := os.WriteFile(filepath.Join(dir, "pbcopy"), []byte("#!/bin/sh\nexit 0\n"), 0o755)
+		require.NoError(t, err)
+		t.Setenv("PATH", dir)
+		t.Setenv("WAYLAND_DISPLAY", "")
+		t.Setenv("DISPLAY", "")
+		errBuf := setStderr(t)
+
+		setOSArgs(t, []string{"fcqs-cli", "--copy", "aws"})
+
+		var buf bytes.Buffer
+		err = run(&buf)
+
+		require.NoError(t, err)
+		assert.Empty(t, buf.String())
+		assert.Equal(t, "warning: line 4 of copied text: possible secret: AWS access key ID\n"+
+			"warning: line 5 of copied text: possible secret: AWS secret access key\n", errBuf.String())
+	})
+}
+
+func TestRunWithInvalidLintCommand(t *testing.T) {
+	t.Setenv("FCQS_NOTES_FILE", test.NotesFile)
+	t.Setenv("FCQS_NOTES_FILES", "")
+
+	t.Run("with output flag", func(t *testing.T) {
+		setCommandLineFlag(t, "secrets")
+		setCommandLineFlag(t, "command")
+		setOSArgs(t, []string{"fcqs-cli", "lint", "--secrets", "-c"})
+
+		var buf bytes.Buffer
+		err := run(&buf)
+
+		require.ErrorIs(t, err, ErrLintWithOutputFlag)
+		assert.Empty(t, buf.String())
+	})
+}
+
+func TestRunWithNoteTitledLint(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "fcnotes.md")
+	err := os.WriteFile(fileName, []byte("# lint\n\n```sh\nmake lint\n```\n"), 0o600)
+	require.NoError(t, err)
+	t.Setenv("FCQS_NOTES_FILE", fileName)
+	t.Setenv("FCQS_NOTES_FILES", "")
+
+	t.Run("contents", func(t *testing.T) {
+		setOSArgs(t, []string{"fcqs-cli", "lint"})
+
+		var buf bytes.Buffer
+		err := run(&buf)
+
+		require.NoError(t, err)
+		assert.Equal(t, "# lint\n\n```sh\nmake lint\n```\n", buf.String())
+	})
+
+	t.Run("command", func(t *testing.T) {
+		setCommandLineFlag(t, "command")
+		setOSArgs(t, []string{"fcqs-cli", "-c", "lint"})
+
+		var buf bytes.Buffer
+		err := run(&buf)
+
+		require.NoError(t, err)
+		assert.Equal(t, "make lint\n", buf.String())
+	})
+}
+
+func TestRunWithLintCommand(t *testing.T) {
+	setCommandLineFlag(t, "secrets")
+
+	t.Run("with secrets", func(t *testing.T) {
+		t.Setenv("FCQS_NOTES_FILE", test.SecretsFile)
+		t.Setenv("FCQS_NOTES_FILES", "")
+		setOSArgs(t, []string{"fcqs-cli", "lint", "--secrets"})
+
+		var buf bytes.Buffer
+		err := run(&buf)
+
+		var statusErr *exitStatusError
+		require.ErrorAs(t, err, &statusErr)
+		assert.Equal(t, 1, statusErr.status)
+		assert.Contains(t, buf.String(), fmt.Sprintf("%s:4: possible secret: AWS access key ID\n", test.SecretsFile))
+	})
+
+	t.Run("without secrets", func(t *testing.T) {
+		t.Setenv("FCQS_NOTES_FILE", test.NotesFile)
+		t.Setenv("FCQS_NOTES_FILES", "")
+		setOSArgs(t, []string{"fcqs-cli", "lint", "--secrets"})
+
+		var buf bytes.Buffer
+		err := run(&buf)
+
+		require.NoError(t, err)
+		assert.Empty(t, buf.String())
+	})
+
+	t.Run("without subcommand", func(t *testing.T) {
+		t.Setenv("FCQS_NOTES_FILE", test.NotesFile)
+		t.Setenv("FCQS_NOTES_FILES", "")
+		setOSArgs(t, []string{"fcqs-cli", "--secrets"})
+
+		var buf bytes.Buffer
+		err := run(&buf)
+
+		require.EqualError(t, err, "invalid number of arguments")
+		assert.Empty(t, buf.String())
+	})
+}
+
+func TestRunWithRunFlag(t *testing.T) {
+	t.Setenv("FCQS_NOTES_FILE", test.RunFile)
+	t.Setenv("FCQS_NOTES_FILES", "")
+	t.Setenv("FCQS_DANGER_RULES", "")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("SHELL", "/bin/sh")
+	setCommandLineFlag(t, "run")
+
+	t.Run("confirmed", func(t *testing.T) {
+		historyFile := filepath.Join(t.TempDir(), "history.jsonl")
+		t.Setenv("FCQS_HISTORY_FILE", historyFile)
+		setStdin(t, "y\n")
+		setOSArgs(t, []string{"fcqs-cli", "--run", "greet"})
+
+		var buf bytes.Buffer
+		err := run(&buf)
+
+		require.NoError(t, err)
+		expected := fmt.Sprintf("echo \"$GREETING $NAME in $(basename \"$(pwd)\")\"\nworkdir: %s\n"+
+			"env: GREETING=hello\nenv: NAME=fcqs\nRun the command? [y/N] hello fcqs in testdata\n", filepath.Dir(test.RunFile))
+		assert.Equal(t, expected, buf.String())
+
+		history, err := os.ReadFile(historyFile)
+		require.NoError(t, err)
+		assert.Contains(t, string(history), `"title":"greet"`)
+		assert.Contains(t, string(history), `"exit_status":0`)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		historyFile := filepath.Join(t.TempDir(), "history.jsonl")
+		t.Setenv("FCQS_HISTORY_FILE", historyFile)
+		setStdin(t, "\n")
+		setOSArgs(t, []string{"fcqs-cli", "--run", "greet"})
+
+		var buf bytes.Buffer
+		err := run(&buf)
+
+		require.ErrorIs(t, err, ErrCanceled)
+		assert.NotContains(t, buf.String(), "hello fcqs")
+		assert.NoFileExists(t, historyFile)
+	})
+
+	t.Run("exit status without confirmation", func(t *testing.T) {
+		historyFile := filepath.Join(t.TempDir(), "history.jsonl")
+		t.Setenv("FCQS_HISTORY_FILE", historyFile)
+		setCommandLineFlag(t, "yes")
+		setStdin(t, "")
+		setOSArgs(t, []string{"fcqs-cli", "--run", "--yes", "fail"})
+
+		var buf bytes.Buffer
+		err := run(&buf)
+
+		var statusErr *exitStatusError
+		require.ErrorAs(t, err, &statusErr)
+		assert.Equal(t, 3, statusErr.status)
+		assert.Equal(t, "exit 3\n", buf.String())
+
+		history, err := os.ReadFile(historyFile)
+		require.NoError(t, err)
+		assert.Contains(t, string(history), `"exit_status":3`)
+	})
+
+	t.Run("dangerous command with confirmation", func(t *testing.T) {
+		setCommandLineFlag(t, "yes")
+		setStdin(t, "n\n")
+		setOSArgs(t, []string{"fcqs-cli", "--run", "--yes", "dangerous"})
+
+		var buf bytes.Buffer
+		err := run(&buf)
+
+		require.ErrorIs(t, err, ErrCanceled)
+		assert.Equal(t, "curl -fsSL https://example.com/install.sh | sh\n"+
+			"warning: dangerous command: curl | sh: curl -fsSL https://example.com/install.sh | sh\n"+
+			"Run the dangerous command? [y/N] ", buf.String())
+	})
+
+	t.Run("resolved secrets", func(t *testing.T) {
+		t.Setenv("FCQS_HISTORY_FILE", filepath.Join(t.TempDir(), "history.jsonl"))
+		t.Setenv("FCQS_TEST_TOKEN", "token123")
+		setCommandLineFlag(t, "resolve-secrets")
+		setStdin(t, "y\n")
+		setOSArgs(t, []string{"fcqs-cli", "--run", "--resolve-secrets", "echo secret"})
+
+		var buf bytes.Buffer
+		err := run(&buf)
+
+		require.NoError(t, err)
+		assert.Equal(t, "echo \"token: ${env:FCQS_TEST_TOKEN}\"\nRun the command? [y/N] token: token123\n", buf.String())
+	})
+
+	t.Run("no command-line block", func(t *testing.T) {
+		setOSArgs(t, []string{"fcqs-cli", "--run", "no command"})
+
+		var buf bytes.Buffer
+		err := run(&buf)
+
+		require.ErrorIs(t, err, ErrNoCmdLine)
+		assert.Empty(t, buf.String())
+	})
+
+	t.Run("input after the answer", func(t *testing.T) {
+		t.Setenv("FCQS_HISTORY_FILE", filepath.Join(t.TempDir(), "history.jsonl"))
+		setStdin(t, "y\ninput for the command\n")
+		setOSArgs(t, []string{"fcqs-cli", "--run", "read input"})
+
+		var buf bytes.Buffer
+		err := run(&buf)
+
+		require.NoError(t, err)
+		assert.Equal(t, "read line\necho \"read: $line\"\nRun the command? [y/N] read: input for the command\n", buf.String())
+	})
+
+	t.Run("without title", func(t *testing.T) {
+		setOSArgs(t, []string{"fcqs-cli", "--run"})
+
+		var buf bytes.Buffer
+		err := run(&buf)
+
+		require.ErrorIs(t, err, ErrInvalidNumberOfArgs)
+		assert.Empty(t, buf.String())
+	})
+}
+
+func TestRunWithCheckFlag(t *testing.T) {
+	t.Setenv("FCQS_NOTES_FILE", test.RunFile)
+	t.Setenv("FCQS_NOTES_FILES", "")
+	t.Setenv("FCQS_DANGER_RULES", "")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	setCommandLineFlag(t, "check")
+
+	t.Run("dangerous command", func(t *testing.T) {
+		setOSArgs(t, []string{"fcqs-cli", "--check", "dangerous"})
+
+		var buf bytes.Buffer
+		err := run(&buf)
+
+		var statusErr *exitStatusError
+		require.ErrorAs(t, err, &statusErr)
+		assert.Equal(t, dangerExitStatus, statusErr.status)
+		assert.Equal(t, "warning: dangerous command: curl | sh: curl -fsSL https://example.com/install.sh | sh\n", buf.String())
+	})
+
+	t.Run("safe command", func(t *testing.T) {
+		setOSArgs(t, []string{"fcqs-cli", "--check", "greet"})
+
+		var buf bytes.Buffer
+		err := run(&buf)
+
+		require.NoError(t, err)
+		assert.Empty(t, buf.String())
+	})
+}
+
+func TestRunWithRenderFlag(t *testing.T) {
+	t.Setenv("FCQS_NOTES_FILE", test.NotesFile)
+	t.Setenv("FCQS_NOTES_FILES", "")
+	t.Setenv("FZF_PREVIEW_COLUMNS", "")
+	t.Setenv("COLUMNS", "12")
+	t.Setenv("FCQS_DANGER_RULES", "")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	setCommandLineFlag(t, "render")
+
+	t.Run("with color", func(t *testing.T) {
+		t.Setenv("NO_COLOR", "")
+		setOSArgs(t, []string{"fcqs-cli", "--render", "URL"})
+
+		var buf bytes.Buffer
+		err := run(&buf)
+
+		require.NoError(t, err)
+		assert.Equal(t, "\x1b[1mURL\x1b[0m\n\n"+
+			"fcqs: \x1b]8;;http://github.com/yendo/fcqs/\x1b\\\x1b[4mhttp://github.com/yendo/fcqs/\x1b[0m\x1b]8;;\x1b\\\n"+
+			"github: \x1b]8;;http://github.com/\x1b\\\x1b[4mhttp://github.com/\x1b[0m\x1b]8;;\x1b\\\n", buf.String())
+	})
+
+	t.Run("without color", func(t *testing.T) {
+		t.Setenv("NO_COLOR", "1")
+		t.Setenv("FZF_PREVIEW_COLUMNS", "10")
+		setOSArgs(t, []string{"fcqs-cli", "--render", "command-line"})
+
+		var buf bytes.Buffer
+		err := run(&buf)
+
+		require.NoError(t, err)
+		assert.Equal(t, "command-line\n\n┌─ sh ───┐\n│ ls -l  │\n│ | nl   │\n└────────┘\n", buf.String())
+	})
+
+	t.Run("with danger rules not found", func(t *testing.T) {
+		t.Setenv("NO_COLOR", "1")
+		t.Setenv("FZF_PREVIEW_COLUMNS", "10")
+		t.Setenv("FCQS_DANGER_RULES", filepath.Join(t.TempDir(), "missing.yaml"))
+		errBuf := setStderr(t)
+		setOSArgs(t, []string{"fcqs-cli", "--render", "command-line"})
+
+		var buf bytes.Buffer
+		err := run(&buf)
+
+		require.NoError(t, err)
+		assert.Equal(t, "command-line\n\n┌─ sh ───┐\n│ ls -l  │\n│ | nl   │\n└────────┘\n", buf.String())
+		assert.Contains(t, errBuf.String(), "warning: danger rules: open ")
+	})
+}
+
 func TestRunWithCmdFlag(t *testing.T) {
 	t.Setenv("FCQS_NOTES_FILE", test.NotesFile)
 	t.Setenv("FCQS_NOTES_FILES", "")
@@ -236,6 +780,73 @@ func TestRunWithCmdFlag(t *testing.T) {
 	})
 }
 
+func TestRunWithResolveSecretsFlag(t *testing.T) {
+	t.Setenv("FCQS_NOTES_FILE", test.RunFile)
+	t.Setenv("FCQS_NOTES_FILES", "")
+	t.Setenv("FCQS_TEST_TOKEN", "token123")
+	setCommandLineFlag(t, "command")
+
+	t.Run("without resolving secrets", func(t *testing.T) {
+		setOSArgs(t, []string{"fcqs-cli", "-c", "echo secret"})
+
+		var buf bytes.Buffer
+		err := run(&buf)
+
+		require.NoError(t, err)
+		assert.Equal(t, "echo \"token: ${env:FCQS_TEST_TOKEN}\"\n", buf.String())
+	})
+
+	t.Run("with resolving secrets", func(t *testing.T) {
+		setCommandLineFlag(t, "resolve-secrets")
+		setOSArgs(t, []string{"fcqs-cli", "-c", "--resolve-secrets", "echo secret"})
+
+		var buf bytes.Buffer
+		err := run(&buf)
+
+		require.NoError(t, err)
+		assert.Equal(t, "echo \"token: token123\"\n", buf.String())
+	})
+	t.Run("with expanding secrets", func(t *testing.T) {
+		setCommandLineFlag(t, "expand-secrets")
+		setOSArgs(t, []string{"fcqs-cli", "-c", "--expand-secrets", "echo secret"})
+
+		var buf bytes.Buffer
+		err := run(&buf)
+
+		require.NoError(t, err)
+		assert.Equal(t, "echo \"token: ${FCQS_TEST_TOKEN}\"\n", buf.String())
+	})
+}
+
+func TestRunWithJoinFlag(t *testing.T) {
+	t.Setenv("FCQS_NOTES_FILE", test.SessionFile)
+	t.Setenv("FCQS_NOTES_FILES", "")
+	setCommandLineFlag(t, "command")
+
+	t.Run("join with separator", func(t *testing.T) {
+		setCommandLineStringFlag(t, "join", ";")
+		setOSArgs(t, []string{"fcqs-cli", "-c", "--join", ";", "console session"})
+
+		var buf bytes.Buffer
+		err := run(&buf)
+
+		require.NoError(t, err)
+		assert.Equal(t, "cd /tmp; ls; echo \"one two\"; for i in 1 2; do echo $i; done\n", buf.String())
+	})
+
+	t.Run("invalid separator", func(t *testing.T) {
+		setCommandLineStringFlag(t, "join", "|")
+		setOSArgs(t, []string{"fcqs-cli", "-c", "--join", "|", "console session"})
+
+		var buf bytes.Buffer
+		err := run(&buf)
+
+		require.Error(t, err)
+		require.EqualError(t, err, "invalid separator to join")
+		assert.Empty(t, buf.String())
+	})
+}
+
 func TestRunWithLocationFlag(t *testing.T) {
 	testFileName := test.LocationFile
 	t.Setenv("FCQS_NOTES_FILE", testFileName)
@@ -264,6 +875,191 @@ func TestRunWithLocationFlag(t *testing.T) {
 	})
 }
 
+func TestRunWithSeparateFlag(t *testing.T) {
+	t.Setenv("FCQS_NOTES_FILE", test.MultiFiles(test.LocationFile, test.LocationExtraFile))
+	t.Setenv("FCQS_NOTES_FILES", "")
+	setCommandLineFlag(t, "separate")
+	setOSArgs(t, []string{"fcqs-cli", "-s"})
+
+	var buf bytes.Buffer
+	err := run(&buf)
+
+	require.NoError(t, err)
+	assert.Equal(t, "location test data [test_location.md]\n5th Line\n"+
+		"location test data [test_location_extra.md]\nother 5th Line\n9th Line\n", buf.String())
+}
+
+func TestRunWithIDFlag(t *testing.T) {
+	t.Setenv("FCQS_NOTES_FILE", test.IDFile)
+	t.Setenv("FCQS_NOTES_FILES", "")
+
+	t.Run("with an ID", func(t *testing.T) {
+		setOSArgs(t, []string{"fcqs-cli", "--id", "k8s-rollout"})
+		setCommandLineStringFlag(t, "id", "k8s-rollout")
+
+		var buf bytes.Buffer
+		err := run(&buf)
+
+		require.NoError(t, err)
+		assert.Equal(t, "# rollout status {#k8s-rollout}\n\nkubectl rollout status deployment\n", buf.String())
+	})
+
+	t.Run("with an ID and location flag", func(t *testing.T) {
+		setOSArgs(t, []string{"fcqs-cli", "-l", "--id", "bc177c27c4b6"})
+		setCommandLineFlag(t, "location")
+		setCommandLineStringFlag(t, "id", "bc177c27c4b6")
+
+		var buf bytes.Buffer
+		err := run(&buf)
+
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("%q 5\n", test.IDFile), buf.String())
+	})
+
+	t.Run("with an ID and a title", func(t *testing.T) {
+		setOSArgs(t, []string{"fcqs-cli", "--id", "k8s-rollout", "rollout status"})
+		setCommandLineStringFlag(t, "id", "k8s-rollout")
+
+		var buf bytes.Buffer
+		err := run(&buf)
+
+		require.EqualError(t, err, "invalid number of arguments")
+		assert.Empty(t, buf.String())
+	})
+
+	t.Run("with a blank ID", func(t *testing.T) {
+		setOSArgs(t, []string{"fcqs-cli", "--id", " "})
+		setCommandLineStringFlag(t, "id", " ")
+
+		var buf bytes.Buffer
+		err := run(&buf)
+
+		require.EqualError(t, err, "ID is empty")
+		assert.Empty(t, buf.String())
+	})
+}
+
+func TestRunWithLinksFlag(t *testing.T) {
+	t.Setenv("FCQS_NOTES_FILE", test.LinksFile)
+	t.Setenv("FCQS_NOTES_FILES", "")
+
+	tests := []struct {
+		flag   string
+		title  string
+		titles string
+	}{
+		{"links", "rollout", "deploy\n"},
+		{"backlinks", "deploy", "rollout status\n"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.flag, func(t *testing.T) {
+			setCommandLineFlag(t, tc.flag)
+
+			t.Run("with no args", func(t *testing.T) {
+				setOSArgs(t, []string{"fcqs-cli", "--" + tc.flag})
+
+				var buf bytes.Buffer
+				err := run(&buf)
+
+				require.EqualError(t, err, "invalid number of arguments")
+				assert.Empty(t, buf.String())
+			})
+
+			t.Run("with a arg", func(t *testing.T) {
+				setOSArgs(t, []string{"fcqs-cli", "--" + tc.flag, tc.title})
+
+				var buf bytes.Buffer
+				err := run(&buf)
+
+				require.NoError(t, err)
+				assert.Equal(t, tc.titles, buf.String())
+			})
+		})
+	}
+}
+
+func TestRunWithFormatFlag(t *testing.T) {
+	t.Setenv("FCQS_NOTES_FILE", test.FrontMatterFile)
+	t.Setenv("FCQS_NOTES_FILES", "")
+
+	t.Run("json", func(t *testing.T) {
+		setOSArgs(t, []string{"fcqs-cli", "--format", "json"})
+		setCommandLineStringFlag(t, "format", "json")
+
+		var buf bytes.Buffer
+		err := run(&buf)
+
+		require.NoError(t, err)
+		expected := fmt.Sprintf(`[
+			{"id": "75b5bf9f56ec", "title": "pod logs", "prefix": "k8s:", "file": %[1]q, "line": 8, "notebook": "work", "shell": "bash", "tags": ["k8s", "ops"]},
+			{"id": "92ac71a755cf", "title": "events", "prefix": "k8s:", "file": %[1]q, "line": 14, "notebook": "work", "shell": "bash", "tags": ["k8s", "ops"]}
+		]`, test.FrontMatterFile)
+		assert.JSONEq(t, expected, buf.String())
+	})
+
+	t.Run("text", func(t *testing.T) {
+		setOSArgs(t, []string{"fcqs-cli", "--format", "text"})
+		setCommandLineStringFlag(t, "format", "text")
+
+		var buf bytes.Buffer
+		err := run(&buf)
+
+		require.NoError(t, err)
+		assert.Equal(t, "k8s: pod logs\nk8s: events\n", buf.String())
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		setOSArgs(t, []string{"fcqs-cli", "--format", "xml"})
+		setCommandLineStringFlag(t, "format", "xml")
+
+		var buf bytes.Buffer
+		err := run(&buf)
+
+		require.Error(t, err)
+		require.EqualError(t, err, "invalid format")
+		assert.Empty(t, buf.String())
+	})
+}
+
+func TestRunWithMetadataFlag(t *testing.T) {
+	t.Setenv("FCQS_NOTES_FILE", test.MetadataFile)
+	t.Setenv("FCQS_NOTES_FILES", "")
+	setCommandLineFlag(t, "metadata")
+
+	t.Run("with no args", func(t *testing.T) {
+		setOSArgs(t, []string{"fcqs-cli", "-m"})
+
+		var buf bytes.Buffer
+		err := run(&buf)
+
+		require.Error(t, err)
+		require.EqualError(t, err, "invalid number of arguments")
+		assert.Empty(t, buf.String())
+	})
+
+	t.Run("with a arg", func(t *testing.T) {
+		setOSArgs(t, []string{"fcqs-cli", "-m", "events"})
+
+		var buf bytes.Buffer
+		err := run(&buf)
+
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("id: 3d21a5e60aaa\ntitle: events\nfile: %s\nline: 16\ntags: ops\npinned: true\n", test.MetadataFile), buf.String())
+	})
+
+	t.Run("with a arg in JSON", func(t *testing.T) {
+		setOSArgs(t, []string{"fcqs-cli", "-m", "--format", "json", "events"})
+		setCommandLineStringFlag(t, "format", "json")
+
+		var buf bytes.Buffer
+		err := run(&buf)
+
+		require.NoError(t, err)
+		expected := fmt.Sprintf(`{"id": "3d21a5e60aaa", "title": "events", "file": %q, "line": 16, "tags": ["ops"], "pinned": true}`, test.MetadataFile)
+		assert.JSONEq(t, expected, buf.String())
+	})
+}
+
 func TestRunWithVersionFlag(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,197 @@
+package fcqs
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+const (
+	// defaultPrompt is the prompt of commands in all shell languages,
+	// and continuationPrompt is the prompt of continued commands in shell sessions.
+	defaultPrompt      = "$ "
+	continuationPrompt = ">"
+
+	// rootPrompt is the prompt of commands run by root in shell sessions.
+	rootPrompt = "# "
+
+	lineContinuation = `\`
+)
+
+// langPrompts are the prompts of commands in the shell languages in addition to the default prompt.
+var langPrompts = map[string][]string{
+	"console":      {rootPrompt},
+	"shellsession": {rootPrompt},
+	"zsh":          {"% "},
+	"powershell":   {"PS> "},
+	"posh":         {"PS> "},
+	"pwsh":         {"PS> "},
+}
+
+// promptsOf returns the prompts of commands in the code in the shell language.
+// The root prompt is not a prompt in the code with the default prompt,
+// where the lines starting with "# " are the outputs like comments.
+func promptsOf(code []string, lang string) []string {
+	prompts := []string{defaultPrompt}
+	hasDefaultPrompt := slices.ContainsFunc(code, func(line string) bool {
+		_, ok := cutPrompt(line, prompts)
+		return ok
+	})
+
+	for _, prompt := range langPrompts[lang] {
+		if prompt == rootPrompt && hasDefaultPrompt {
+			continue
+		}
+		prompts = append(prompts, prompt)
+	}
+
+	return prompts
+}
+
+// cutPrompt returns the command line without the prompt,
+// and reports whether the line has the prompt. Spaces before the prompt are ignored.
+// The line is returned as it is without the prompt.
+func cutPrompt(line string, prompts []string) (string, bool) {
+	trimmed := strings.TrimLeft(line, " \t")
+
+	for _, prompt := range prompts {
+		if cmd, ok := strings.CutPrefix(trimmed, prompt); ok {
+			return cmd, true
+		}
+		if trimmed == strings.TrimRight(prompt, " ") {
+			return "", true
+		}
+	}
+
+	return line, false
+}
+
+// sessionCommands returns the command lines without the prompts in the lines of a shell session.
+// A command line starts with the prompt, and continues to the lines with the continuation prompt
+// while the command is incomplete, or after the line ending with a backslash.
+// The other lines are outputs of the commands.
+func sessionCommands(code []string, prompts []string) []string {
+	var lines []string
+	var command []string
+	isContinued := false
+
+	for _, line := range code {
+		trimmed := strings.TrimLeft(line, " \t")
+
+		switch cmd, hasPrompt := cutPrompt(line, prompts); {
+		case isContinued:
+		case hasPrompt:
+			line = cmd
+			command = nil
+		case isIncomplete(command) && strings.HasPrefix(trimmed, continuationPrompt):
+			line = strings.TrimPrefix(strings.TrimPrefix(trimmed, continuationPrompt), " ")
+		default:
+			command = nil
+			continue
+		}
+
+		lines = append(lines, line)
+		command = append(command, line)
+		isContinued = strings.HasSuffix(line, lineContinuation)
+	}
+
+	return lines
+}
+
+// isIncomplete reports whether the command lines need more lines to be parsed as bash.
+func isIncomplete(command []string) bool {
+	if len(command) == 0 {
+		return false
+	}
+
+	_, err := syntax.NewParser(syntax.Variant(syntax.LangBash)).Parse(strings.NewReader(strings.Join(command, "\n")), "")
+	return syntax.IsIncomplete(err)
+}
+
+// joinCommands joins the command lines in the code into one line with the separator like "&&" or ";".
+// The lines are parsed as bash, and the commands at the top level are joined with the separator,
+// with the compound commands like "for ...; do" and the lines continued by backslashes in one line.
+// Blank lines and comments are removed.
+// The lines are joined with newlines as they are if they have here documents, which cannot be in one line,
+// or they cannot be parsed.
+func joinCommands(code, sep string) string {
+	f, err := syntax.NewParser(syntax.Variant(syntax.LangBash)).Parse(strings.NewReader(code), "")
+	if err != nil || hasHeredoc(f) {
+		return code
+	}
+
+	separator := " " + sep + " "
+	if sep == ";" {
+		separator = "; "
+	}
+
+	var sb strings.Builder
+	printer := syntax.NewPrinter(syntax.SingleLine(true), syntax.SpaceRedirects(true))
+
+	for i, stmt := range f.Stmts {
+		if i > 0 {
+			sb.WriteString(separator)
+		}
+
+		// The command in the background like "cmd &" is grouped not to put the other commands in the background.
+		isGrouped := stmt.Background && len(f.Stmts) > 1
+		if isGrouped {
+			sb.WriteString("{ ")
+		}
+		if err := printer.Print(&sb, stmt); err != nil {
+			return code
+		}
+		if isGrouped {
+			sb.WriteString(" }")
+		}
+	}
+
+	return sb.String()
+}
+
+// hasHeredoc reports whether the shell code has here documents.
+func hasHeredoc(f *syntax.File) bool {
+	found := false
+	syntax.Walk(f, func(node syntax.Node) bool {
+		if r, ok := node.(*syntax.Redirect); ok && (r.Op == syntax.Hdoc || r.Op == syntax.DashHdoc) {
+			found = true
+		}
+		return !found
+	})
+
+	return found
+}
+
+// quoteCommands returns the multi-line command lines as one line like "eval $'line1\nline2'"
+// quoted in the ANSI-C quoting of bash, to keep here documents and indents in the command-line.
+// A single command line is returned as it is.
+func quoteCommands(code string) string {
+	if !strings.Contains(code, "\n") {
+		return code
+	}
+
+	var sb strings.Builder
+	sb.WriteString("eval $'")
+
+	for _, r := range code {
+		switch {
+		case r == '\\' || r == '\'':
+			sb.WriteRune('\\')
+			sb.WriteRune(r)
+		case r == '\n':
+			sb.WriteString(`\n`)
+		case r == '\t':
+			sb.WriteString(`\t`)
+		case r < ' ' || r == 0x7f:
+			fmt.Fprintf(&sb, `\x%02x`, r)
+		default:
+			sb.WriteRune(r)
+		}
+	}
+
+	sb.WriteString("'")
+
+	return sb.String()
+}
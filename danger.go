@@ -0,0 +1,150 @@
+package fcqs
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultDangerRulesFile is the file of the danger rules in the config directory.
+const defaultDangerRulesFile = "fcqs/danger_rules.yaml"
+
+// DangerRule represents a rule to detect a dangerous command.
+type DangerRule struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// DangerRules represents the rules to detect dangerous commands.
+type DangerRules []DangerRule
+
+// DangerWarning represents a line of a command detected by a danger rule.
+type DangerWarning struct {
+	Rule string
+	Text string
+}
+
+// String returns the warning like "dangerous command: rule: text".
+func (dw DangerWarning) String() string {
+	return fmt.Sprintf("dangerous command: %s: %s", dw.Rule, dw.Text)
+}
+
+// defaultDangerRules are the rules used without the danger rules file.
+var defaultDangerRules = DangerRules{
+	{"rm -rf /", regexp.MustCompile(`\brm\s+(?:-\S+\s+)*-\S*[rR]\S*\s+(?:-\S+\s+)*(?:/\*?|~/?|\$HOME/?)(?:[\s;&|]|$)`)},
+	{"dd to device", regexp.MustCompile(`\bdd\s.*\bof=/dev/`)},
+	{"mkfs", regexp.MustCompile(`\bmkfs(?:\.\w+)?\b`)},
+	{"curl | sh", regexp.MustCompile(`\b(?:curl|wget)\b[^|]*\|\s*(?:sudo\s+)?(?:ba|z|k|da)?sh\b`)},
+	{"force push", regexp.MustCompile(`\bgit\s+push\b.*\s(?:--force|-f)(?:\s|$)`)},
+	{"DROP TABLE", regexp.MustCompile(`(?i)\bdrop\s+(?:table|database|schema)\b`)},
+}
+
+// dangerRuleConfig represents a rule in the danger rules file.
+type dangerRuleConfig struct {
+	Name     string `yaml:"name"`
+	Pattern  string `yaml:"pattern"`
+	Disabled bool   `yaml:"disabled"`
+}
+
+// LoadDangerRules returns the default danger rules with the rules in the danger rules file.
+// The file is FCQS_DANGER_RULES, or "fcqs/danger_rules.yaml" in the XDG config directory.
+// A rule in the file replaces the default rule with the same name, or disables it.
+func LoadDangerRules() (DangerRules, error) {
+	rules := slices.Clone(defaultDangerRules)
+
+	// The default danger rules file is optional.
+	fileName, isDefault := dangerRulesFileName()
+	if fileName == "" {
+		return rules, nil
+	}
+
+	data, err := os.ReadFile(fileName)
+	if errors.Is(err, fs.ErrNotExist) && isDefault {
+		return rules, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("danger rules: %w", err)
+	}
+
+	var configs []dangerRuleConfig
+	if err := yaml.Unmarshal(data, &configs); err != nil {
+		return nil, fmt.Errorf("danger rules: %w", err)
+	}
+
+	for _, c := range configs {
+		rules = slices.DeleteFunc(rules, func(r DangerRule) bool { return r.Name == c.Name })
+		if c.Disabled {
+			continue
+		}
+
+		pattern, err := regexp.Compile(c.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("danger rules: %s: %w", c.Name, err)
+		}
+		rules = append(rules, DangerRule{Name: c.Name, Pattern: pattern})
+	}
+
+	return rules, nil
+}
+
+// Check returns the warnings for the lines of the code detected by the rules.
+// The lines continued by backslashes are checked as one line.
+func (rules DangerRules) Check(code string) []DangerWarning {
+	var warnings []DangerWarning
+
+	for _, line := range joinContinuedLines(code) {
+		for _, rule := range rules {
+			if rule.Pattern.MatchString(line) {
+				warnings = append(warnings, DangerWarning{Rule: rule.Name, Text: strings.TrimSpace(line)})
+			}
+		}
+	}
+
+	return warnings
+}
+
+// joinContinuedLines returns the lines of the code with the lines continued by backslashes joined into one line.
+func joinContinuedLines(code string) []string {
+	var lines []string
+	var continued string
+
+	for _, line := range strings.Split(code, "\n") {
+		if continued != "" {
+			line = continued + " " + strings.TrimLeft(line, " \t")
+		}
+
+		if l, ok := strings.CutSuffix(line, lineContinuation); ok {
+			continued = strings.TrimRight(l, " \t")
+			continue
+		}
+		continued = ""
+		lines = append(lines, line)
+	}
+	if continued != "" {
+		lines = append(lines, continued)
+	}
+
+	return lines
+}
+
+// dangerRulesFileName returns the name of the danger rules file, and whether it is the default one.
+// The name is empty if the config directory is unknown.
+func dangerRulesFileName() (string, bool) {
+	if f := os.Getenv("FCQS_DANGER_RULES"); f != "" {
+		return f, false
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", true
+	}
+
+	return filepath.Join(dir, defaultDangerRulesFile), true
+}
@@ -0,0 +1,135 @@
+package fcqs_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yendo/fcqs"
+)
+
+func TestDangerRulesCheck(t *testing.T) {
+	t.Setenv("FCQS_DANGER_RULES", "")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	rules, err := fcqs.LoadDangerRules()
+	require.NoError(t, err)
+
+	tests := []struct {
+		code string
+		rule string
+	}{
+		{"rm -rf /", "rm -rf /"},
+		{"sudo rm -fr /*", "rm -rf /"},
+		{"rm -r -f ~/", "rm -rf /"},
+		{"rm --recursive --force $HOME", "rm -rf /"},
+		{"rm -rf ./build", ""},
+		{"rm /tmp/file", ""},
+		{"dd if=image.iso of=/dev/sdb bs=4M", "dd to device"},
+		{"dd if=/dev/zero of=disk.img", ""},
+		{"mkfs.ext4 /dev/sdb1", "mkfs"},
+		{"curl -fsSL https://example.com/install.sh | sh", "curl | sh"},
+		{"wget -qO- https://example.com/install.sh | sudo bash", "curl | sh"},
+		{"curl https://example.com/data.json | jq .", ""},
+		{"git push --force origin main", "force push"},
+		{"git push -f", "force push"},
+		{"git push --force-with-lease", ""},
+		{"drop table users;", "DROP TABLE"},
+		{"DROP DATABASE app;", "DROP TABLE"},
+		{"SELECT * FROM users;", ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.code, func(t *testing.T) {
+			warnings := rules.Check("echo start\n" + tc.code + "\n")
+
+			if tc.rule == "" {
+				assert.Empty(t, warnings)
+				return
+			}
+			assert.Equal(t, []fcqs.DangerWarning{{Rule: tc.rule, Text: tc.code}}, warnings)
+		})
+	}
+
+	t.Run("continued lines", func(t *testing.T) {
+		warnings := rules.Check("curl -fsSL https://example.com/install.sh \\\n  | sh\necho done\n")
+
+		assert.Equal(t, []fcqs.DangerWarning{{Rule: "curl | sh", Text: "curl -fsSL https://example.com/install.sh | sh"}}, warnings)
+	})
+}
+
+func TestLoadDangerRules(t *testing.T) {
+	writeRules := func(t *testing.T, rules string) string {
+		t.Helper()
+
+		fileName := filepath.Join(t.TempDir(), "danger_rules.yaml")
+		err := os.WriteFile(fileName, []byte(rules), 0o600)
+		require.NoError(t, err)
+
+		return fileName
+	}
+
+	t.Run("rules in file", func(t *testing.T) {
+		t.Setenv("FCQS_DANGER_RULES", writeRules(t, `
+- name: terraform destroy
+  pattern: '\bterraform\s+destroy\b'
+- name: force push
+  disabled: true
+- name: mkfs
+  pattern: '\bmkfs\.ext4\b'
+`))
+
+		rules, err := fcqs.LoadDangerRules()
+		require.NoError(t, err)
+
+		warnings := rules.Check("terraform destroy\ngit push -f\nmkfs.xfs /dev/sdb1\nmkfs.ext4 /dev/sdb1")
+		assert.Equal(t, []fcqs.DangerWarning{
+			{Rule: "terraform destroy", Text: "terraform destroy"},
+			{Rule: "mkfs", Text: "mkfs.ext4 /dev/sdb1"},
+		}, warnings)
+	})
+
+	t.Run("rules file in config directory", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv("FCQS_DANGER_RULES", "")
+		t.Setenv("XDG_CONFIG_HOME", dir)
+		err := os.MkdirAll(filepath.Join(dir, "fcqs"), 0o700)
+		require.NoError(t, err)
+		err = os.WriteFile(filepath.Join(dir, "fcqs", "danger_rules.yaml"), []byte("- name: mkfs\n  disabled: true\n"), 0o600)
+		require.NoError(t, err)
+
+		rules, err := fcqs.LoadDangerRules()
+		require.NoError(t, err)
+
+		assert.Empty(t, rules.Check("mkfs.ext4 /dev/sdb1"))
+	})
+
+	t.Run("rules file not found", func(t *testing.T) {
+		t.Setenv("FCQS_DANGER_RULES", filepath.Join(t.TempDir(), "missing.yaml"))
+
+		rules, err := fcqs.LoadDangerRules()
+
+		require.ErrorContains(t, err, "danger rules: open ")
+		assert.Nil(t, rules)
+	})
+
+	t.Run("invalid rules file", func(t *testing.T) {
+		t.Setenv("FCQS_DANGER_RULES", writeRules(t, "name: not a list\n"))
+
+		rules, err := fcqs.LoadDangerRules()
+
+		require.ErrorContains(t, err, "danger rules: yaml: unmarshal errors")
+		assert.Nil(t, rules)
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		t.Setenv("FCQS_DANGER_RULES", writeRules(t, "- name: invalid\n  pattern: '('\n"))
+
+		rules, err := fcqs.LoadDangerRules()
+
+		require.EqualError(t, err, "danger rules: invalid: error parsing regexp: missing closing ): `(`")
+		assert.Nil(t, rules)
+	})
+}
@@ -24,3 +24,15 @@ func SetNewScannerMock(t *testing.T, e error) {
 		newScanner = tmp
 	})
 }
+
+// SetWarnWriter sets the writer for warnings.
+func SetWarnWriter(t *testing.T, w io.Writer) {
+	t.Helper()
+
+	tmp := warnWriter
+	warnWriter = w
+
+	t.Cleanup(func() {
+		warnWriter = tmp
+	})
+}
@@ -3,129 +3,401 @@ package fcqs
 import (
 	"bufio"
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"slices"
 	"strings"
 
+	"github.com/yendo/fcqs/internal/render"
 	"github.com/yendo/fcqs/internal/value"
-	"mvdan.cc/xurls/v2"
 )
 
-const (
-	DefaultNotesFile = "fcnotes.md"
+const DefaultNotesFile = "fcnotes.md"
 
-	shellPrompt = "$"
+// ListOptions represents options of the title list.
+type ListOptions struct {
+	// Aliases lists the aliases of the notes after their titles.
+	Aliases bool
 
-	// State of text line.
-	normal = iota
-	fenced
-	scoped
-	scopedFenced
-)
+	// Separate lists notes with duplicate titles separately with their locators like "title [file]",
+	// instead of merging them into one.
+	Separate bool
+
+	// MatchPolicy is the policy to find duplicate titles.
+	MatchPolicy value.MatchPolicy
+}
 
 // WriteTitles writes the titles of all notes.
 func WriteTitles(w io.Writer, r io.Reader) error {
-	var allTitles []value.Title
-	var title value.Title
+	return WriteTitlesWithOptions(w, r, ListOptions{})
+}
+
+// WriteTitlesWithOptions writes the titles of all notes with the options.
+func WriteTitlesWithOptions(w io.Writer, r io.Reader, opts ListOptions) error {
+	notes, err := listNotes(r, opts)
+	if err != nil {
+		return err
+	}
 
-	scanner := bufio.NewScanner(r)
-	state := normal
+	allTitles := make([]string, 0, len(notes))
+	for _, n := range notes {
+		allTitles = append(allTitles, opts.MatchPolicy.Key(n.title))
+	}
 
-	for scanner.Scan() {
-		line := scanner.Text()
-		if line == "" {
+	for _, n := range notes {
+		fmt.Fprintln(w, n.title)
+
+		if !opts.Aliases {
 			continue
 		}
-
-		switch state {
-		case normal:
-			if value.IsFenceLine(line) {
-				state = fenced
-			} else if tl, ok := value.NewTitleLine(line); ok && tl.HasValidTitle() {
-				title = tl.Title()
-				continue
+		for _, alias := range n.aliases() {
+			a := n.frontMatter.displayTitle(alias)
+			if key := opts.MatchPolicy.Key(a); !slices.Contains(allTitles, key) {
+				fmt.Fprintln(w, a)
+				allTitles = append(allTitles, key)
 			}
+		}
+	}
 
-			if !slices.Contains(allTitles, title) {
-				fmt.Fprintln(w, title)
-				allTitles = append(allTitles, title)
-			}
+	return nil
+}
 
-		case fenced:
-			if value.IsFenceLine(line) {
-				state = normal
-			}
+// WriteTitlesJSON writes the information of all notes in JSON.
+// Notes with duplicate titles are always listed separately.
+func WriteTitlesJSON(w io.Writer, r io.Reader, opts ListOptions) error {
+	opts.Separate = true
+	notes, err := listNotes(r, opts)
+	if err != nil {
+		return err
+	}
+
+	infos := make([]*noteInfo, 0, len(notes))
+	for _, n := range notes {
+		infos = append(infos, n.info())
+	}
+
+	return writeJSON(w, infos)
+}
+
+// listedNote represents a note in the title list.
+type listedNote struct {
+	*note
+	title string
+}
+
+// listNotes returns the notes in the title list. Pinned notes come first.
+func listNotes(r io.Reader, opts ListOptions) ([]listedNote, error) {
+	notes, err := seekTitles(r)
+	if err != nil {
+		return nil, err
+	}
+
+	duplicates := make(map[string][]*note)
+	for _, n := range notes {
+		key := opts.MatchPolicy.Key(n.displayTitle())
+		duplicates[key] = append(duplicates[key], n)
+	}
+
+	listed := make([]listedNote, 0, len(notes))
+	for _, n := range notes {
+		dups := duplicates[opts.MatchPolicy.Key(n.displayTitle())]
+
+		switch {
+		case len(dups) == 1:
+			listed = append(listed, listedNote{note: n, title: n.displayTitle()})
+		case opts.Separate:
+			// The path is needed for duplicate titles in files with the same base name,
+			// and the line number for duplicate titles in the same file.
+			withPath := slices.ContainsFunc(dups, func(d *note) bool {
+				return d.fileName != n.fileName && filepath.Base(d.fileName) == filepath.Base(n.fileName)
+			})
+			withLine := slices.ContainsFunc(dups, func(d *note) bool { return d != n && d.fileName == n.fileName })
+			listed = append(listed, listedNote{note: n, title: value.JoinLocator(n.displayTitle(), n.locator(withPath, withLine))})
+		case dups[0] == n:
+			listed = append(listed, listedNote{note: n, title: n.displayTitle()})
+		}
+	}
+
+	pinned := slices.DeleteFunc(slices.Clone(listed), func(n listedNote) bool { return !n.isPinned() })
+	unpinned := slices.DeleteFunc(listed, func(n listedNote) bool { return n.isPinned() })
+
+	return append(pinned, unpinned...), nil
+}
+
+// seekTitles returns the notes that have contents.
+func seekTitles(r io.Reader) ([]*note, error) {
+	var notes []*note
+	seen := make(map[*note]bool)
+
+	scanner := newNotesScanner(sourcesOf(r)...)
+
+	for scanner.Scan() {
+		line := scanner.Line()
+		if line.text == "" || line.kind == titleLine || line.kind == underline || line.kind == metadataLine || line.kind == includeLine {
+			continue
+		}
+
+		if n := scanner.Note(); n != nil && !seen[n] {
+			seen[n] = true
+			notes = append(notes, n)
 		}
 	}
 	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("seek titles: %w", err)
+		return nil, fmt.Errorf("seek titles: %w", err)
 	}
 
-	return nil
+	return notes, nil
 }
 
 // WriteContents writes the contents of the note.
-func WriteContents(w io.Writer, r io.Reader, title *value.Title, isNoTitle bool) error {
+// The metadata of the note in an HTML comment below the title is hidden.
+func WriteContents(w io.Writer, r io.Reader, ref value.NoteRef, isNoTitle bool) error {
+	_, err := writeContents(w, r, ref, isNoTitle, false)
+	return err
+}
+
+// WriteContentsWithMetadata writes the contents of the note with the HTML comment of its metadata.
+func WriteContentsWithMetadata(w io.Writer, r io.Reader, ref value.NoteRef, isNoTitle bool) error {
+	_, err := writeContents(w, r, ref, isNoTitle, true)
+	return err
+}
+
+// WriteRenderedContents writes the contents of the note rendered for terminals,
+// with the warnings for the dangerous commands in the fenced code blocks detected by the rules.
+func WriteRenderedContents(w io.Writer, r io.Reader, ref value.NoteRef, isNoTitle bool, opts render.Options, rules DangerRules) error {
+	var buf bytes.Buffer
+	if err := WriteContents(&buf, r, ref, isNoTitle); err != nil {
+		return err
+	}
+
+	blocks, err := parseBlocks(bytes.NewReader(buf.Bytes()), "")
+	if err != nil {
+		return err
+	}
+	for _, b := range blocks {
+		for _, dw := range rules.Check(b.code()) {
+			opts.Warnings = append(opts.Warnings, dw.String())
+		}
+	}
+
+	return render.Write(w, &buf, opts)
+}
+
+// writeContents writes the contents of the note and returns the first note referred to by the reference, or nil if not found.
+// The metadata of the note is written only with withMetadata.
+// The contents are written while the notes are scanned, and the scan stops at the end of the note
+// if the reference refers to only one note. The notes are read again only to expand transclusions.
+func writeContents(w io.Writer, r io.Reader, ref value.NoteRef, isNoTitle, withMetadata bool) (*note, error) {
+	src, record := recordUnseekable(r)
+
 	f := newFilter(w, isNoTitle)
 	defer f.Close()
 
-	state := normal
+	tr := &transclusion{policy: matchPolicyOf(ref)}
 
-	scanner := bufio.NewScanner(r)
+	// The contents from the first transclusion directive are deferred until all notes are read.
+	var deferred []*noteContents
+	var first, current, matched *note
+
+	scanner := newNotesScanner(sourcesOf(src)...)
 	for scanner.Scan() {
-		line := scanner.Text()
+		line := scanner.Line()
 
-		switch state {
-		case normal:
-			if value.IsFenceLine(line) {
-				state = fenced
-			} else if tl, ok := value.NewTitleLine(line); ok && tl.EqualTitle(title) {
-				state = scoped
-				fmt.Fprint(f, line)
+		// The note ends at the next title in its file, not in the included files.
+		if line.kind == titleLine && first != nil && line.fileName == first.fileName && isUniqueRef(ref) {
+			break
+		}
+
+		if n := scanner.Note(); n != current {
+			current, matched = n, nil
+			if n != nil && n.refersTo(ref) {
+				matched = n
+				if first == nil {
+					first = n
+				}
 			}
+		}
+
+		switch {
+		case matched == nil || line.kind == includeLine:
+			continue
+		case line.kind == metadataLine && !withMetadata:
+			continue
+		case line.kind == underline && isNoTitle && matched == first:
+			// The underline of a setext title is removed with the title.
+			continue
+		}
 
-		case fenced:
-			if value.IsFenceLine(line) {
-				state = normal
+		if _, ok := tr.directive(line); ok || len(deferred) > 0 {
+			if len(deferred) == 0 || deferred[len(deferred)-1].note != matched {
+				deferred = append(deferred, &noteContents{note: matched})
 			}
+			last := deferred[len(deferred)-1]
+			last.lines = append(last.lines, line)
+			continue
+		}
 
-		case scoped:
-			if value.IsFenceLine(line) {
-				state = scopedFenced
-			} else if tl, ok := value.NewTitleLine(line); ok && !tl.EqualTitle(title) {
-				state = normal
-				break
+		fmt.Fprint(f, line.text)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("seek contents: %w", err)
+	}
+
+	if len(deferred) > 0 {
+		notes, err := reseekContents(r, record)
+		if err != nil {
+			return nil, err
+		}
+
+		tr.notes = notes
+		for _, d := range deferred {
+			n := d
+			if i := slices.IndexFunc(notes, func(n *noteContents) bool { return n.isAt(d.note) }); i >= 0 {
+				n = notes[i]
 			}
+			tr.write(f, d.lines, []*noteContents{n})
+		}
+	}
+
+	return first, nil
+}
+
+// recordUnseekable returns the reader that records the notes read from the reader if it cannot seek,
+// and the record to read the notes again. The reader is returned as it is if it can seek.
+func recordUnseekable(r io.Reader) (io.Reader, *bytes.Buffer) {
+	switch r.(type) {
+	case *notesReader, io.Seeker:
+		return r, nil
+	}
 
-			fmt.Fprint(f, line)
+	var record bytes.Buffer
+	return io.TeeReader(r, &record), &record
+}
 
-		case scopedFenced:
-			if value.IsFenceLine(line) {
-				state = scoped
+// reseekContents reads the notes again from the beginning and returns all notes with their contents.
+// record is the notes already read from the reader that cannot seek.
+// The warnings about the notes files are not written again.
+func reseekContents(r io.Reader, record *bytes.Buffer) ([]*noteContents, error) {
+	switch r := r.(type) {
+	case *notesReader:
+		for _, f := range r.files {
+			if _, err := f.Seek(0, io.SeekStart); err != nil {
+				return nil, fmt.Errorf("seek contents: %w", err)
 			}
-			fmt.Fprint(f, line)
 		}
+	case io.Seeker:
+		if _, err := r.Seek(0, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("seek contents: %w", err)
+		}
+	}
+
+	src := r
+	if record != nil {
+		src = io.MultiReader(bytes.NewReader(record.Bytes()), r)
+	}
+
+	scanner := newNotesScanner(sourcesOf(src)...)
+	scanner.quiet = true
+
+	return scanContents(scanner)
+}
+
+// noteContents represents a note with the lines of its contents.
+type noteContents struct {
+	*note
+	lines []line
+}
+
+// body returns the lines of the contents without the title and the metadata.
+func (n *noteContents) body() []line {
+	lines := n.lines[1:]
+	if len(lines) > 0 && lines[0].kind == underline {
+		lines = lines[1:]
+	}
+
+	return slices.DeleteFunc(slices.Clone(lines), func(l line) bool { return l.kind == metadataLine })
+}
+
+// seekContents returns all notes with their contents.
+// The contents start with the title line and do not have the include directive lines.
+func seekContents(r io.Reader) ([]*noteContents, error) {
+	return scanContents(newNotesScanner(sourcesOf(r)...))
+}
+
+// scanContents returns all notes with their contents scanned by the scanner.
+func scanContents(scanner *notesScanner) ([]*noteContents, error) {
+	var notes []*noteContents
+	contents := make(map[*note]*noteContents)
+
+	for scanner.Scan() {
+		line := scanner.Line()
+
+		n := scanner.Note()
+		if n == nil || line.kind == includeLine {
+			continue
+		}
+
+		c, ok := contents[n]
+		if !ok {
+			c = &noteContents{note: n}
+			contents[n] = c
+			notes = append(notes, c)
+		}
+		c.lines = append(c.lines, line)
 	}
 	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("seek contents: %w", err)
+		return nil, fmt.Errorf("seek contents: %w", err)
+	}
+
+	return notes, nil
+}
+
+// WriteMetadata writes the metadata of the note.
+func WriteMetadata(w io.Writer, r io.Reader, ref value.NoteRef) error {
+	n, err := seekNote(r, ref)
+	if err != nil || n == nil {
+		return err
 	}
 
+	n.info().writeText(w)
 	return nil
 }
 
-// WriteFirstURL writes the first URL in the contents of the note.
-func WriteFirstURL(w io.Writer, r io.Reader, title *value.Title) error {
-	var buf bytes.Buffer
-	if err := WriteContents(&buf, r, title, false); err != nil {
+// WriteMetadataJSON writes the metadata of the note in JSON.
+func WriteMetadataJSON(w io.Writer, r io.Reader, ref value.NoteRef) error {
+	n, err := seekNote(r, ref)
+	if err != nil || n == nil {
 		return err
 	}
 
-	rxStrict := xurls.Strict()
+	return writeJSON(w, n.info())
+}
+
+// seekNote returns the first note referred to by the reference, or nil if not found.
+func seekNote(r io.Reader, ref value.NoteRef) (*note, error) {
+	scanner := newNotesScanner(sourcesOf(r)...)
 
-	if url := rxStrict.FindString(buf.String()); url != "" {
-		fmt.Fprintln(w, url)
+	for scanner.Scan() {
+		if line := scanner.Line(); line.refersTo(ref) {
+			return line.note, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("seek note: %w", err)
+	}
+
+	return nil, nil
+}
+
+// writeJSON writes the value in indented JSON.
+func writeJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return fmt.Errorf("encode JSON: %w", err)
 	}
 
 	return nil
@@ -134,14 +406,75 @@ func WriteFirstURL(w io.Writer, r io.Reader, title *value.Title) error {
 // newScanner is to replace bufio.NewScanner for test.
 var newScanner = bufio.NewScanner
 
+// CmdLineOptions represents options to write command-line blocks.
+type CmdLineOptions struct {
+	// ResolveSecrets replaces the secret references like "${env:NAME}" and "${cmd:command}"
+	// with their values. The references are written as they are without it.
+	ResolveSecrets bool
+
+	// ExpandSecrets replaces the secret references with the shell expansions like "${NAME}" and "$(command)"
+	// to resolve them only when the command-line runs in the shell. It is ignored with ResolveSecrets.
+	ExpandSecrets bool
+
+	// Join joins the command lines into one line with the separator like "&&" or ";".
+	// The command lines are written as they are if it is empty.
+	Join string
+
+	// Quote writes the multi-line command lines as one line like "eval $'line1\nline2'"
+	// to paste them into the command-line of bash intact.
+	Quote bool
+}
+
 // WriteFirstCmdLineBlock writes the first command-line block in the contents of the note.
-func WriteFirstCmdLineBlock(w io.Writer, r io.Reader, title *value.Title) error {
-	state := normal
+func WriteFirstCmdLineBlock(w io.Writer, r io.Reader, ref value.NoteRef) error {
+	return WriteFirstCmdLineBlockWithOptions(w, r, ref, CmdLineOptions{})
+}
 
-	var buf bytes.Buffer
-	if err := WriteContents(&buf, r, title, false); err != nil {
+// WriteFirstCmdLineBlockWithOptions writes the first command-line block in the contents of the note with the options.
+func WriteFirstCmdLineBlockWithOptions(w io.Writer, r io.Reader, ref value.NoteRef, opts CmdLineOptions) error {
+	lines, _, err := seekFirstCmdLineBlock(r, ref)
+	if err != nil {
 		return err
 	}
+
+	code := strings.Join(lines, "\n")
+	switch {
+	case opts.ResolveSecrets:
+		if code, err = ResolveSecrets(code); err != nil {
+			return err
+		}
+	case opts.ExpandSecrets:
+		if code, err = ExpandSecrets(code); err != nil {
+			return err
+		}
+	}
+	// The commands are joined after the secret references, which cannot be parsed as shell, are replaced.
+	if opts.Join != "" {
+		code = joinCommands(code, opts.Join)
+	}
+	if opts.Quote {
+		code = quoteCommands(code)
+	}
+
+	if len(lines) > 0 {
+		fmt.Fprintln(w, code)
+	}
+
+	return nil
+}
+
+// seekFirstCmdLineBlock returns the command lines in the first command-line block in the contents of the note,
+// and the first note referred to by the reference.
+func seekFirstCmdLineBlock(r io.Reader, ref value.NoteRef) ([]string, *note, error) {
+	var opening *value.FenceLine
+	var code []string
+
+	var buf bytes.Buffer
+	n, err := writeContents(&buf, r, ref, false, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	fm := n.fileFrontMatter()
 	scanner := newScanner(&buf)
 
 loop:
@@ -149,39 +482,64 @@ loop:
 		line := scanner.Text()
 		fenceLine, isFenceLine := value.NewFenceLine(line)
 
-		switch state {
-		case normal:
-			if isFenceLine && fenceLine.HasShellID() {
-				state = fenced
+		switch {
+		case opening == nil:
+			if isFenceLine {
+				opening = fenceLine
 			}
 
-		case fenced:
-			if isFenceLine {
+		case isFenceLine && fenceLine.Closes(opening):
+			if fm.isShellBlock(opening) {
 				break loop
 			}
-			fmt.Fprintln(w, strings.TrimLeft(line, shellPrompt+" "))
+			opening = nil
+
+		case fm.isShellBlock(opening):
+			code = append(code, line)
 		}
 	}
 	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("seek command line block: %w", err)
+		return nil, nil, fmt.Errorf("seek command line block: %w", err)
 	}
 
-	return nil
+	if opening == nil {
+		return code, n, nil
+	}
+	lang := fm.blockLang(opening)
+	prompts := promptsOf(code, lang)
+
+	// A shell session without prompts is treated as command lines.
+	if value.IsSessionID(lang) {
+		if lines := sessionCommands(code, prompts); len(lines) > 0 {
+			return lines, n, nil
+		}
+	}
+
+	lines := make([]string, 0, len(code))
+	for _, line := range code {
+		cmd, _ := cutPrompt(line, prompts)
+		lines = append(lines, cmd)
+	}
+
+	return lines, n, nil
 }
 
 // WriteNoteLocation writes the file name and line number of the note.
-func WriteNoteLocation(w io.Writer, files []*os.File, title *value.Title) error {
+func WriteNoteLocation(w io.Writer, files []*os.File, ref value.NoteRef) error {
+	// The occurrences are shared so that the generated IDs are the same as in all files.
+	occurrences := make(map[string]int)
+
 	for _, file := range files {
-		c := 0
-		scanner := newScanner(file)
+		scanner := newNotesScanner(source{name: file.Name(), scanner: newScanner(file)})
+		scanner.occurrences = occurrences
 
+		found := false
 		for scanner.Scan() {
-			c++
-			line := scanner.Text()
+			line := scanner.Line()
 
-			if tl, ok := value.NewTitleLine(line); ok && tl.EqualTitle(title) {
-				fmt.Fprintf(w, "%q %d\n", file.Name(), c)
-				break
+			if !found && line.refersTo(ref) {
+				fmt.Fprintf(w, "%q %d\n", line.fileName, line.num)
+				found = true
 			}
 		}
 		if err := scanner.Err(); err != nil {
@@ -4,7 +4,10 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
 	"testing"
 	"testing/iotest"
@@ -12,6 +15,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/yendo/fcqs"
+	"github.com/yendo/fcqs/internal/render"
 	"github.com/yendo/fcqs/internal/value"
 	"github.com/yendo/fcqs/test"
 )
@@ -85,6 +89,8 @@ func TestWriteContents(t *testing.T) {
 		{"# URL\n", "fcqs: http://github.com/yendo/fcqs/\n" + "github: http://github.com/\n"},
 		{"# command-line\n", "```sh\n" + "ls -l | nl\n" + "```\n"},
 		{"# command-line with $\n", "```console\n" + "$ date\n" + "```\n"},
+		{"# Headings in tilde fenced code blocks are ignored\n", "~~~\n" + "# fenced heading\n" + "~~~\n"},
+		{"# Fences inside a longer fence are contents\n", "````markdown\n" + "```sh\n" + "# fenced heading\n" + "```\n" + "````\n"},
 	}
 
 	t.Run("contents with title", func(t *testing.T) {
@@ -179,6 +185,41 @@ func TestWriteNoContents(t *testing.T) {
 	})
 }
 
+func TestWriteRenderedContents(t *testing.T) {
+	t.Parallel()
+
+	rules := fcqs.DangerRules{{Name: "line numbers", Pattern: regexp.MustCompile(`\bnl\b`)}}
+
+	tests := []struct {
+		name      string
+		isNoTitle bool
+		opts      render.Options
+		rules     fcqs.DangerRules
+		expected  string
+	}{
+		{"with title", false, render.Options{Color: true, Width: 80}, nil, "\x1b[1mcommand-line\x1b[0m\n\n" +
+			"\x1b[2m┌─ sh ───────┐\x1b[0m\n\x1b[2m│\x1b[0m ls -l | nl \x1b[2m│\x1b[0m\n\x1b[2m└────────────┘\x1b[0m\n"},
+		{"without title", true, render.Options{Color: false, Width: 12}, nil, "┌─ sh ─────┐\n│ ls -l |  │\n│ nl       │\n└──────────┘\n"},
+		{"dangerous command", true, render.Options{Color: false, Width: 80}, rules,
+			"⚠ dangerous command: line numbers: ls -l | nl\n\n┌─ sh ───────┐\n│ ls -l | nl │\n└────────────┘\n"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			file := openTestNotesFile(t, test.NotesFile)
+			title, err := value.NewTitle("command-line")
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			err = fcqs.WriteRenderedContents(&buf, file, title, tc.isNoTitle, tc.opts, tc.rules)
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, buf.String())
+		})
+	}
+}
+
 func TestWriteFirstURL(t *testing.T) {
 	t.Parallel()
 
@@ -197,6 +238,20 @@ func TestWriteFirstURL(t *testing.T) {
 		assert.Equal(t, "http://github.com/yendo/fcqs/\n", buf.String())
 	})
 
+	t.Run("matched URL", func(t *testing.T) {
+		t.Parallel()
+
+		file := openTestNotesFile(t, test.URLsFile)
+		title, err := value.NewTitle("links")
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		err = fcqs.WriteFirstURLWithOptions(&buf, file, title, fcqs.URLOptions{Match: "pkg.go.dev"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "https://pkg.go.dev/\n", buf.String())
+	})
+
 	t.Run("scan failed", func(t *testing.T) {
 		t.Parallel()
 
@@ -210,6 +265,64 @@ func TestWriteFirstURL(t *testing.T) {
 	})
 }
 
+func TestWriteURLs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		title string
+		opts  fcqs.URLOptions
+		urls  string
+	}{
+		{
+			"all URLs", "links", fcqs.URLOptions{},
+			"fcqs: http://github.com/yendo/fcqs/\nthe Go docs: https://go.dev/doc/\nhttps://pkg.go.dev/\nhttps://example.com/path_(1)\n",
+		},
+		{"markdown link", "single link", fcqs.URLOptions{}, "Kubernetes: https://kubernetes.io/docs/\n"},
+		{"no link", "no link", fcqs.URLOptions{}, ""},
+		{"unknown note", "unknown", fcqs.URLOptions{}, ""},
+		{"domain", "links", fcqs.URLOptions{Match: "GitHub.com"}, "fcqs: http://github.com/yendo/fcqs/\n"},
+		{"subdomain", "links", fcqs.URLOptions{Match: "go.dev"}, "the Go docs: https://go.dev/doc/\nhttps://pkg.go.dev/\n"},
+		{"scheme", "links", fcqs.URLOptions{Match: "http://"}, "fcqs: http://github.com/yendo/fcqs/\n"},
+		{"label", "links", fcqs.URLOptions{Match: "go docs"}, "the Go docs: https://go.dev/doc/\n"},
+		{"no match", "links", fcqs.URLOptions{Match: "kubernetes"}, ""},
+		{"strict", "relaxed links", fcqs.URLOptions{}, "docs: https://go.dev/doc/\n"},
+		{
+			"relaxed", "relaxed links", fcqs.URLOptions{Relaxed: true},
+			"repository: https://github.com/yendo/fcqs\nmail: mailto:fcqs@example.com\ndocs: https://go.dev/doc/\n",
+		},
+		{"relaxed mail", "relaxed links", fcqs.URLOptions{Relaxed: true, Match: "mailto"}, "mail: mailto:fcqs@example.com\n"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			file := openTestNotesFile(t, test.URLsFile)
+			title, err := value.NewTitle(tc.title)
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			err = fcqs.WriteURLsWithOptions(&buf, file, title, tc.opts)
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.urls, buf.String())
+		})
+	}
+
+	t.Run("scan error", func(t *testing.T) {
+		t.Parallel()
+
+		title, err := value.NewTitle("title")
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		err = fcqs.WriteURLs(&buf, iotest.ErrReader(ErrScanForTest), title)
+
+		require.EqualError(t, err, fmt.Sprintf("seek contents: %s", ErrScanForTest))
+		assert.Empty(t, buf.String())
+	})
+}
+
 func TestWriteFirstCmdLine(t *testing.T) {
 	// Cannot run tests in parallel due to fcqs.SetNewScannerMock
 
@@ -233,6 +346,9 @@ func TestWriteFirstCmdLine(t *testing.T) {
 		{"go", false},
 		{"no identifier", false},
 		{"other identifier", false},
+		{"tilde", true},
+		{"indented fence", true},
+		{"markdown example", false},
 	}
 
 	for _, tc := range tests {
@@ -294,6 +410,241 @@ func TestWriteFirstCmdLine(t *testing.T) {
 	})
 }
 
+func TestWriteFirstCmdLineWithSecrets(t *testing.T) {
+	t.Setenv("SHELL", "/bin/sh")
+	t.Setenv("FCQS_TEST_TOKEN", "token123")
+
+	tests := []struct {
+		name     string
+		opts     fcqs.CmdLineOptions
+		expected string
+	}{
+		{"references", fcqs.CmdLineOptions{}, "curl -H \"Authorization: Bearer ${env:FCQS_TEST_TOKEN}\" https://api.example.com/${cmd:echo v1}\n"},
+		{"resolved secrets", fcqs.CmdLineOptions{ResolveSecrets: true}, "curl -H \"Authorization: Bearer token123\" https://api.example.com/v1\n"},
+		{"expanded secrets", fcqs.CmdLineOptions{ExpandSecrets: true}, "curl -H \"Authorization: Bearer ${FCQS_TEST_TOKEN}\" https://api.example.com/\"$(echo v1)\"\n"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			file := openTestNotesFile(t, test.RunFile)
+			title, err := value.NewTitle("secret refs")
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			err = fcqs.WriteFirstCmdLineBlockWithOptions(&buf, file, title, tc.opts)
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, buf.String())
+		})
+	}
+
+	joinTests := []struct {
+		name     string
+		opts     fcqs.CmdLineOptions
+		expected string
+	}{
+		{"joined references", fcqs.CmdLineOptions{Join: "&&"}, "cd /tmp\ncurl https://api.example.com/${cmd:echo v1}\n"},
+		{"joined resolved secrets", fcqs.CmdLineOptions{Join: "&&", ResolveSecrets: true}, "cd /tmp && curl https://api.example.com/v1\n"},
+		{"joined expanded secrets", fcqs.CmdLineOptions{Join: "&&", ExpandSecrets: true}, "cd /tmp && curl https://api.example.com/\"$(echo v1)\"\n"},
+	}
+
+	for _, tc := range joinTests {
+		t.Run(tc.name, func(t *testing.T) {
+			file := openTestNotesFile(t, test.RunFile)
+			title, err := value.NewTitle("joined secret refs")
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			err = fcqs.WriteFirstCmdLineBlockWithOptions(&buf, file, title, tc.opts)
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, buf.String())
+		})
+	}
+
+	t.Run("secret not set", func(t *testing.T) {
+		t.Setenv("FCQS_TEST_TOKEN", "")
+		os.Unsetenv("FCQS_TEST_TOKEN")
+
+		file := openTestNotesFile(t, test.RunFile)
+		title, err := value.NewTitle("secret refs")
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		err = fcqs.WriteFirstCmdLineBlockWithOptions(&buf, file, title, fcqs.CmdLineOptions{ResolveSecrets: true})
+
+		require.EqualError(t, err, "resolve secret: env:FCQS_TEST_TOKEN: not set")
+		assert.Empty(t, buf.String())
+	})
+}
+
+func TestWriteFirstCmdLineInSession(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		title    string
+		opts     fcqs.CmdLineOptions
+		expected string
+	}{
+		{"console session", fcqs.CmdLineOptions{}, "cd /tmp\nls\necho \"one \\\ntwo\"\nfor i in 1 2; do\necho $i\ndone\n"},
+		{"console session", fcqs.CmdLineOptions{Join: "&&"}, "cd /tmp && ls && echo \"one two\" && for i in 1 2; do echo $i; done\n"},
+		{"indented prompt", fcqs.CmdLineOptions{}, "date\n"},
+		{"join commands", fcqs.CmdLineOptions{Join: "&&"}, "cd src && make install && if [ -f done ]; then echo done; fi && for f in *.txt; do cat \"$f\" | wc -l; done\n"},
+		{"join commands", fcqs.CmdLineOptions{Join: ";"}, "cd src; make install; if [ -f done ]; then echo done; fi; for f in *.txt; do cat \"$f\" | wc -l; done\n"},
+		{"here document", fcqs.CmdLineOptions{Join: "&&"}, "cat <<EOF > hello.txt\nhello\nEOF\ncat hello.txt\n"},
+		{"here string", fcqs.CmdLineOptions{Join: "&&"}, "cat <<< \"hello\" && echo done\n"},
+		{"indented here document", fcqs.CmdLineOptions{Quote: true}, "eval $'cat <<\\'END\\'\\n  it\\'s indented\\nEND'\n"},
+		{"here string", fcqs.CmdLineOptions{Join: "&&", Quote: true}, "cat <<< \"hello\" && echo done\n"},
+		{"trailing comment", fcqs.CmdLineOptions{Join: "&&"}, "cd src && make\n"},
+		{"case command", fcqs.CmdLineOptions{Join: ";"}, "case \"$1\" in a) echo a ;; *) echo other ;; esac; echo end\n"},
+		{"comment after continuation", fcqs.CmdLineOptions{Join: "&&"}, "make && install\n"},
+		{"background command", fcqs.CmdLineOptions{Join: "&&"}, "{ sleep 1 & } && echo started\n"},
+		{"incomplete command", fcqs.CmdLineOptions{Join: "&&"}, "for i in 1 2; do\n  echo $i\n"},
+		{"output with continuation prompt", fcqs.CmdLineOptions{}, "diff a b\nls\n"},
+	}
+
+	for _, tc := range tests {
+		t.Run(fmt.Sprintf("%s %+v", tc.title, tc.opts), func(t *testing.T) {
+			t.Parallel()
+
+			file := openTestNotesFile(t, test.SessionFile)
+			title, err := value.NewTitle(tc.title)
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			err = fcqs.WriteFirstCmdLineBlockWithOptions(&buf, file, title, tc.opts)
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, buf.String())
+		})
+	}
+}
+
+func TestWriteFirstCmdLineWithPrompts(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		title    string
+		expected string
+	}{
+		{"variables at line start", "$HOME/bin/run\n  $$\n"},
+		{"root prompt", "apt update\nwhoami\n"},
+		{"comment in output", "cat setup.sh\n. setup.sh\n"},
+		{"powershell prompt", "Get-ChildItem\n"},
+		{"zsh prompt", "ls -l\n"},
+		{"comment in shell", "# not a prompt\ndate\n"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			file := openTestNotesFile(t, test.SessionFile)
+			title, err := value.NewTitle(tc.title)
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			err = fcqs.WriteFirstCmdLineBlock(&buf, file, title)
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, buf.String())
+		})
+	}
+}
+
+func TestWriteBlock(t *testing.T) {
+	// Cannot run tests in parallel due to fcqs.SetWarnWriter
+
+	fcqs.SetWarnWriter(t, io.Discard)
+
+	tests := []struct {
+		name  string
+		title string
+		opts  fcqs.BlockOptions
+		code  string
+	}{
+		{"first block", "deployment", fcqs.BlockOptions{Index: 1}, "kubectl apply -f deployment.yaml\n"},
+		{"block without language", "deployment", fcqs.BlockOptions{Index: 4}, "no language\n"},
+		{"block in language", "deployment", fcqs.BlockOptions{Index: 1, Lang: "yaml"}, "apiVersion: apps/v1\nkind: Deployment\n"},
+		{"block in language ignoring cases", "deployment", fcqs.BlockOptions{Index: 2, Lang: "yaml"}, "\nreplicas: 3\n"},
+		{"index out of range", "deployment", fcqs.BlockOptions{Index: 5}, ""},
+		{"zero index", "deployment", fcqs.BlockOptions{Index: 0}, ""},
+		{"unknown language", "deployment", fcqs.BlockOptions{Index: 1, Lang: "go"}, ""},
+		{"no block", "no block", fcqs.BlockOptions{Index: 1}, ""},
+		{"unterminated block", "unterminated block", fcqs.BlockOptions{Index: 1}, "{\"key\": \"value\"}\n"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			file := openTestNotesFile(t, test.BlocksFile)
+			title, err := value.NewTitle(tc.title)
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			err = fcqs.WriteBlock(&buf, file, title, tc.opts)
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.code, buf.String())
+		})
+	}
+
+	t.Run("scan error to seek contents", func(t *testing.T) {
+		r := iotest.ErrReader(ErrScanForTest)
+		title, err := value.NewTitle("title")
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		err = fcqs.WriteBlock(&buf, r, title, fcqs.BlockOptions{Index: 1})
+
+		require.EqualError(t, err, fmt.Sprintf("seek contents: %s", ErrScanForTest))
+		assert.Empty(t, buf.String())
+	})
+
+	t.Run("scan error to seek blocks", func(t *testing.T) {
+		fcqs.SetNewScannerMock(t, ErrScanForTest)
+
+		file := openTestNotesFile(t, test.BlocksFile)
+		title, err := value.NewTitle("deployment")
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		err = fcqs.WriteBlock(&buf, file, title, fcqs.BlockOptions{Index: 1})
+
+		require.EqualError(t, err, fmt.Sprintf("seek blocks: %s", ErrScanForTest))
+		assert.Empty(t, buf.String())
+	})
+}
+
+func TestWriteBlocks(t *testing.T) {
+	// Cannot run tests in parallel due to fcqs.SetWarnWriter
+
+	fcqs.SetWarnWriter(t, io.Discard)
+
+	tests := []struct {
+		name   string
+		lang   string
+		blocks string
+	}{
+		{"all blocks", "", "1: [sh] kubectl apply -f deployment.yaml\n2: [yaml] apiVersion: apps/v1\n3: [YAML] replicas: 3\n4: no language\n"},
+		{"blocks in language", "yaml", "1: [yaml] apiVersion: apps/v1\n2: [YAML] replicas: 3\n"},
+		{"no blocks in language", "go", ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			file := openTestNotesFile(t, test.BlocksFile)
+			title, err := value.NewTitle("deployment")
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			err = fcqs.WriteBlocks(&buf, file, title, fcqs.BlockOptions{Lang: tc.lang})
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.blocks, buf.String())
+		})
+	}
+}
+
 func TestWriteNoteLocation(t *testing.T) {
 	// Cannot run tests in parallel due to fcqs.SetNewScannerMock
 
@@ -351,6 +702,1080 @@ func TestWriteNoteLocation(t *testing.T) {
 	})
 }
 
+func TestSetextHeadings(t *testing.T) {
+	t.Parallel()
+
+	title, err := value.NewTitle("Setext level 2 title")
+	require.NoError(t, err)
+
+	t.Run("titles", func(t *testing.T) {
+		t.Parallel()
+
+		file := openTestNotesFile(t, test.SetextFile)
+
+		var buf bytes.Buffer
+		err := fcqs.WriteTitles(&buf, file)
+
+		require.NoError(t, err)
+		assert.Equal(t, "Setext title\nSetext level 2 title\nATX title\n", buf.String())
+	})
+
+	t.Run("contents", func(t *testing.T) {
+		t.Parallel()
+
+		file := openTestNotesFile(t, test.SetextFile)
+
+		var buf bytes.Buffer
+		err := fcqs.WriteContents(&buf, file, title, false)
+
+		require.NoError(t, err)
+		assert.Equal(t, "Setext level 2 title\n--------------------\n\ncontents 2\n", buf.String())
+	})
+
+	t.Run("contents without title", func(t *testing.T) {
+		t.Parallel()
+
+		file := openTestNotesFile(t, test.SetextFile)
+
+		var buf bytes.Buffer
+		err := fcqs.WriteContents(&buf, file, title, true)
+
+		require.NoError(t, err)
+		assert.Equal(t, "contents 2\n", buf.String())
+	})
+
+	t.Run("location", func(t *testing.T) {
+		t.Parallel()
+
+		file := openTestNotesFile(t, test.SetextFile)
+
+		var buf bytes.Buffer
+		err := fcqs.WriteNoteLocation(&buf, []*os.File{file}, title)
+
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("%q 6\n", file.Name()), buf.String())
+	})
+}
+
+func TestFrontMatter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("titles with prefix", func(t *testing.T) {
+		t.Parallel()
+
+		file := openTestNotesFile(t, test.FrontMatterFile)
+
+		var buf bytes.Buffer
+		err := fcqs.WriteTitles(&buf, file)
+
+		require.NoError(t, err)
+		assert.Equal(t, "k8s: pod logs\nk8s: events\n", buf.String())
+	})
+
+	t.Run("titles in JSON", func(t *testing.T) {
+		t.Parallel()
+
+		file := openTestNotesFile(t, test.FrontMatterFile)
+
+		var buf bytes.Buffer
+		err := fcqs.WriteTitlesJSON(&buf, file, fcqs.ListOptions{})
+
+		require.NoError(t, err)
+		expected := fmt.Sprintf(`[
+			{"id": "75b5bf9f56ec", "title": "pod logs", "prefix": "k8s:", "file": %[1]q, "line": 8, "notebook": "work", "shell": "bash", "tags": ["k8s", "ops"]},
+			{"id": "92ac71a755cf", "title": "events", "prefix": "k8s:", "file": %[1]q, "line": 14, "notebook": "work", "shell": "bash", "tags": ["k8s", "ops"]}
+		]`, file.Name())
+		assert.JSONEq(t, expected, buf.String())
+	})
+
+	for _, titleStr := range []string{"pod logs", "k8s: pod logs"} {
+		t.Run("contents of "+titleStr, func(t *testing.T) {
+			t.Parallel()
+
+			file := openTestNotesFile(t, test.FrontMatterFile)
+			title, err := value.NewTitle(titleStr)
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			err = fcqs.WriteContents(&buf, file, title, false)
+
+			require.NoError(t, err)
+			assert.Equal(t, "# pod logs\n\n```\nkubectl logs pod\n```\n", buf.String())
+		})
+	}
+
+	t.Run("command-line block in default shell", func(t *testing.T) {
+		t.Parallel()
+
+		file := openTestNotesFile(t, test.FrontMatterFile)
+		title, err := value.NewTitle("k8s: pod logs")
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		err = fcqs.WriteFirstCmdLineBlock(&buf, file, title)
+
+		require.NoError(t, err)
+		assert.Equal(t, "kubectl logs pod\n", buf.String())
+	})
+
+	t.Run("code block in other language", func(t *testing.T) {
+		t.Parallel()
+
+		file := openTestNotesFile(t, test.FrontMatterFile)
+		title, err := value.NewTitle("events")
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		err = fcqs.WriteFirstCmdLineBlock(&buf, file, title)
+
+		require.NoError(t, err)
+		assert.Empty(t, buf.String())
+	})
+
+	t.Run("location", func(t *testing.T) {
+		t.Parallel()
+
+		file := openTestNotesFile(t, test.FrontMatterFile)
+		title, err := value.NewTitle("k8s: events")
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		err = fcqs.WriteNoteLocation(&buf, []*os.File{file}, title)
+
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("%q 14\n", file.Name()), buf.String())
+	})
+
+	t.Run("without closing delimiter", func(t *testing.T) {
+		t.Parallel()
+
+		r := strings.NewReader("---\n# title\ncontents\n")
+
+		var buf bytes.Buffer
+		err := fcqs.WriteTitles(&buf, r)
+
+		require.NoError(t, err)
+		assert.Equal(t, "title\n", buf.String())
+	})
+}
+
+func TestInvalidFrontMatter(t *testing.T) {
+	// Cannot run tests in parallel due to fcqs.SetWarnWriter
+
+	var warn bytes.Buffer
+	fcqs.SetWarnWriter(t, &warn)
+
+	r := strings.NewReader("---\ntags: [\n...\n# title\ncontents\n")
+
+	var buf bytes.Buffer
+	err := fcqs.WriteTitlesJSON(&buf, r, fcqs.ListOptions{})
+
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"id": "22a3d3643db5", "title": "title", "file": "", "line": 4}]`, buf.String())
+	assert.Contains(t, warn.String(), "warning: line 1: invalid front matter: yaml: ")
+}
+
+func TestNoteMetadata(t *testing.T) {
+	t.Parallel()
+
+	t.Run("titles", func(t *testing.T) {
+		t.Parallel()
+
+		file := openTestNotesFile(t, test.MetadataFile)
+
+		var buf bytes.Buffer
+		err := fcqs.WriteTitles(&buf, file)
+
+		require.NoError(t, err)
+		assert.Equal(t, "events\npod logs\ncomment\nkubectl cheat sheet\n", buf.String())
+	})
+
+	t.Run("titles in JSON", func(t *testing.T) {
+		t.Parallel()
+
+		file := openTestNotesFile(t, test.MetadataFile)
+
+		var buf bytes.Buffer
+		err := fcqs.WriteTitlesJSON(&buf, file, fcqs.ListOptions{})
+
+		require.NoError(t, err)
+		expected := fmt.Sprintf(`[
+			{"id": "3d21a5e60aaa", "title": "events", "file": %[1]q, "line": 16, "tags": ["ops"], "pinned": true},
+			{"id": "26ed275a0628", "title": "pod logs", "file": %[1]q, "line": 5, "tags": ["ops", "k8s"], "aliases": ["k8s logs", "pod logs"],
+			 "created": "2024-01-02", "updated": "2024-03-04", "source": "https://kubernetes.io/docs/reference/kubectl/"},
+			{"id": "80f88ea741dc", "title": "comment", "file": %[1]q, "line": 22, "tags": ["ops"]},
+			{"id": "d2eaa5bcbd31", "title": "kubectl cheat sheet", "file": %[1]q, "line": 31, "tags": ["ops"], "aliases": ["kubectl"]}
+		]`, file.Name())
+		assert.JSONEq(t, expected, buf.String())
+	})
+
+	tests := []struct {
+		title    string
+		contents string
+	}{
+		{"pod logs", "# pod logs\n\nkubectl logs\n"},
+		{"events", "# events\n\nkubectl get events\n"},
+		{"comment", "# comment\n\n<!-- this is not metadata -->\n\ncontents\n"},
+		{"only metadata", "# only metadata\n"},
+	}
+	for _, tc := range tests {
+		t.Run("contents of "+tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			file := openTestNotesFile(t, test.MetadataFile)
+			title, err := value.NewTitle(tc.title)
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			err = fcqs.WriteContents(&buf, file, title, false)
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.contents, buf.String())
+		})
+	}
+
+	t.Run("metadata", func(t *testing.T) {
+		t.Parallel()
+
+		file := openTestNotesFile(t, test.MetadataFile)
+		title, err := value.NewTitle("pod logs")
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		err = fcqs.WriteMetadata(&buf, file, title)
+
+		require.NoError(t, err)
+		expected := fmt.Sprintf("id: 26ed275a0628\ntitle: pod logs\nfile: %s\nline: 5\ntags: ops, k8s\naliases: k8s logs, pod logs\n"+
+			"created: 2024-01-02\nupdated: 2024-03-04\nsource: https://kubernetes.io/docs/reference/kubectl/\n", file.Name())
+		assert.Equal(t, expected, buf.String())
+	})
+
+	t.Run("metadata in JSON", func(t *testing.T) {
+		t.Parallel()
+
+		file := openTestNotesFile(t, test.MetadataFile)
+		title, err := value.NewTitle("events")
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		err = fcqs.WriteMetadataJSON(&buf, file, title)
+
+		require.NoError(t, err)
+		expected := fmt.Sprintf(`{"id": "3d21a5e60aaa", "title": "events", "file": %q, "line": 16, "tags": ["ops"], "pinned": true}`, file.Name())
+		assert.JSONEq(t, expected, buf.String())
+	})
+
+	t.Run("metadata to run command", func(t *testing.T) {
+		t.Parallel()
+
+		file := openTestNotesFile(t, test.RunFile)
+		title, err := value.NewTitle("greet")
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		err = fcqs.WriteMetadata(&buf, file, title)
+
+		require.NoError(t, err)
+		assert.Contains(t, buf.String(), "line: 1\nworkdir: ../testdata\nenv: GREETING=hello, NAME=fcqs\n")
+	})
+
+	t.Run("metadata of unknown note", func(t *testing.T) {
+		t.Parallel()
+
+		file := openTestNotesFile(t, test.MetadataFile)
+		title, err := value.NewTitle("unknown")
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		err = fcqs.WriteMetadata(&buf, file, title)
+
+		require.NoError(t, err)
+		assert.Empty(t, buf.String())
+	})
+
+	t.Run("scan failed", func(t *testing.T) {
+		t.Parallel()
+
+		file := iotest.ErrReader(ErrScanForTest)
+		title, err := value.NewTitle("events")
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		err = fcqs.WriteMetadata(&buf, file, title)
+
+		require.EqualError(t, err, fmt.Sprintf("seek note: %s", ErrScanForTest))
+		assert.Empty(t, buf.String())
+	})
+}
+
+func TestInvalidMetadata(t *testing.T) {
+	// Cannot run tests in parallel due to fcqs.SetWarnWriter
+
+	tests := []struct {
+		title    string
+		contents string
+		metadata string
+	}{
+		{"invalid env", "# invalid env\n\n```sh\necho \"$GREETING\"\n```\n", "line: 1\n"},
+		{"invalid keys", "# invalid keys\n\ncontents\n", "line: 8\ntags: ops\n"},
+		{"not metadata", "# not metadata\n\n<!-- TODO: fix this -->\n", "line: 17\n"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.title, func(t *testing.T) {
+			fcqs.SetWarnWriter(t, io.Discard)
+
+			file := openTestNotesFile(t, test.InvalidMetadataFile)
+			title, err := value.NewTitle(tc.title)
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			err = fcqs.WriteContents(&buf, file, title, false)
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.contents, buf.String())
+
+			_, err = file.Seek(0, io.SeekStart)
+			require.NoError(t, err)
+
+			buf.Reset()
+			err = fcqs.WriteMetadata(&buf, file, title)
+
+			require.NoError(t, err)
+			assert.Contains(t, buf.String(), tc.metadata)
+		})
+	}
+
+	t.Run("warnings", func(t *testing.T) {
+		var warnings bytes.Buffer
+		fcqs.SetWarnWriter(t, &warnings)
+
+		file := openTestNotesFile(t, test.InvalidMetadataFile)
+
+		var buf bytes.Buffer
+		err := fcqs.WriteTitles(&buf, file)
+
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("warning: %[1]s:2: invalid metadata: env: no value: GREETING\n"+
+			"warning: %[1]s:11: invalid metadata: pinned: not a boolean: yes please\n"+
+			"warning: %[1]s:12: invalid metadata: unknown key: author\n", file.Name()), warnings.String())
+	})
+
+	t.Run("contents with metadata", func(t *testing.T) {
+		fcqs.SetWarnWriter(t, io.Discard)
+
+		file := openTestNotesFile(t, test.InvalidMetadataFile)
+		title, err := value.NewTitle("invalid keys")
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		err = fcqs.WriteContentsWithMetadata(&buf, file, title, false)
+
+		require.NoError(t, err)
+		assert.Equal(t, "# invalid keys\n<!--\ntags: ops\npinned: yes please\nauthor: fcqs\n-->\n\ncontents\n", buf.String())
+	})
+}
+
+func TestTitleAliases(t *testing.T) {
+	t.Parallel()
+
+	t.Run("titles with aliases", func(t *testing.T) {
+		t.Parallel()
+
+		file := openTestNotesFile(t, test.MetadataFile)
+
+		var buf bytes.Buffer
+		err := fcqs.WriteTitlesWithOptions(&buf, file, fcqs.ListOptions{Aliases: true})
+
+		require.NoError(t, err)
+		assert.Equal(t, "events\npod logs\nk8s logs\ncomment\nkubectl cheat sheet\nkubectl\n", buf.String())
+	})
+
+	t.Run("contents", func(t *testing.T) {
+		t.Parallel()
+
+		file := openTestNotesFile(t, test.MetadataFile)
+		title, err := value.NewTitle("k8s logs")
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		err = fcqs.WriteContents(&buf, file, title, false)
+
+		require.NoError(t, err)
+		assert.Equal(t, "# pod logs\n\nkubectl logs\n", buf.String())
+	})
+
+	t.Run("first URL", func(t *testing.T) {
+		t.Parallel()
+
+		file := openTestNotesFile(t, test.MetadataFile)
+		title, err := value.NewTitle("kubectl")
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		err = fcqs.WriteFirstURL(&buf, file, title)
+
+		require.NoError(t, err)
+		assert.Equal(t, "https://kubernetes.io/docs/reference/kubectl/quick-reference/\n", buf.String())
+	})
+
+	t.Run("first command-line block", func(t *testing.T) {
+		t.Parallel()
+
+		file := openTestNotesFile(t, test.MetadataFile)
+		title, err := value.NewTitle("kubectl")
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		err = fcqs.WriteFirstCmdLineBlock(&buf, file, title)
+
+		require.NoError(t, err)
+		assert.Equal(t, "kubectl get pods\n", buf.String())
+	})
+
+	t.Run("location", func(t *testing.T) {
+		t.Parallel()
+
+		file := openTestNotesFile(t, test.MetadataFile)
+		title, err := value.NewTitle("k8s logs")
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		err = fcqs.WriteNoteLocation(&buf, []*os.File{file}, title)
+
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("%q 5\n", file.Name()), buf.String())
+	})
+}
+
+func TestMatchPolicy(t *testing.T) {
+	t.Parallel()
+
+	notes := "# Git Rebase\n\ncontents 1\n\n# git  rebase\n\ncontents 2\n\n# other\n\ncontents 3\n"
+
+	t.Run("titles", func(t *testing.T) {
+		t.Parallel()
+
+		tests := []struct {
+			name   string
+			policy value.MatchPolicy
+			titles string
+		}{
+			{name: "exact", policy: value.MatchExact, titles: "Git Rebase\ngit  rebase\nother\n"},
+			{name: "case", policy: value.MatchCaseFold, titles: "Git Rebase\ngit  rebase\nother\n"},
+			{name: "case and space", policy: value.MatchCaseFold | value.MatchSpace, titles: "Git Rebase\nother\n"},
+		}
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				var buf bytes.Buffer
+				err := fcqs.WriteTitlesWithOptions(&buf, strings.NewReader(notes), fcqs.ListOptions{MatchPolicy: tc.policy})
+
+				require.NoError(t, err)
+				assert.Equal(t, tc.titles, buf.String())
+			})
+		}
+	})
+
+	t.Run("contents", func(t *testing.T) {
+		t.Parallel()
+
+		tests := []struct {
+			name     string
+			policy   value.MatchPolicy
+			contents string
+		}{
+			{name: "exact", policy: value.MatchExact, contents: ""},
+			{name: "case", policy: value.MatchCaseFold, contents: "# Git Rebase\n\ncontents 1\n"},
+			{name: "case and space", policy: value.MatchCaseFold | value.MatchSpace,
+				contents: "# Git Rebase\n\ncontents 1\n\n# git  rebase\n\ncontents 2\n"},
+		}
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				title, err := value.NewTitle("git rebase")
+				require.NoError(t, err)
+
+				var buf bytes.Buffer
+				err = fcqs.WriteContents(&buf, strings.NewReader(notes), title.WithMatchPolicy(tc.policy), false)
+
+				require.NoError(t, err)
+				assert.Equal(t, tc.contents, buf.String())
+			})
+		}
+	})
+}
+
+func TestNoteID(t *testing.T) {
+	t.Parallel()
+
+	t.Run("titles in JSON", func(t *testing.T) {
+		t.Parallel()
+
+		file := openTestNotesFile(t, test.IDFile)
+
+		var buf bytes.Buffer
+		err := fcqs.WriteTitlesJSON(&buf, file, fcqs.ListOptions{})
+
+		require.NoError(t, err)
+		expected := fmt.Sprintf(`[
+			{"id": "k8s-rollout", "title": "rollout status", "file": %[1]q, "line": 1},
+			{"id": "bc177c27c4b6", "title": "rollout history", "file": %[1]q, "line": 5},
+			{"id": "setext-id", "title": "Setext title", "file": %[1]q, "line": 9}
+		]`, file.Name())
+		assert.JSONEq(t, expected, buf.String())
+	})
+
+	tests := []struct {
+		name     string
+		id       string
+		contents string
+	}{
+		{"heading ID", "k8s-rollout", "# rollout status {#k8s-rollout}\n\nkubectl rollout status deployment\n"},
+		{"generated ID", "bc177c27c4b6", "# rollout history\n\nkubectl rollout history deployment\n"},
+		{"setext heading ID", "setext-id", "Setext title {#setext-id}\n=========================\n\nsetext contents\n"},
+		{"title is not ID", "rollout status", ""},
+		{"unknown ID", "unknown", ""},
+	}
+	for _, tc := range tests {
+		t.Run("contents by "+tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			file := openTestNotesFile(t, test.IDFile)
+			id, err := value.NewID(tc.id)
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			err = fcqs.WriteContents(&buf, file, id, false)
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.contents, buf.String())
+		})
+	}
+
+	t.Run("title with ID attribute", func(t *testing.T) {
+		t.Parallel()
+
+		file := openTestNotesFile(t, test.IDFile)
+		title, err := value.NewTitle("rollout status")
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		err = fcqs.WriteContents(&buf, file, title, true)
+
+		require.NoError(t, err)
+		assert.Equal(t, "kubectl rollout status deployment\n", buf.String())
+	})
+
+	t.Run("ID does not depend on directory", func(t *testing.T) {
+		t.Parallel()
+
+		notes, err := os.ReadFile(test.IDFile)
+		require.NoError(t, err)
+		fileName := filepath.Join(t.TempDir(), filepath.Base(test.IDFile))
+		require.NoError(t, os.WriteFile(fileName, notes, 0o600))
+
+		file := openTestNotesFile(t, fileName)
+		id, err := value.NewID("bc177c27c4b6")
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		err = fcqs.WriteNoteLocation(&buf, []*os.File{file}, id)
+
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("%q 5\n", fileName), buf.String())
+	})
+
+	t.Run("duplicate titles in JSON", func(t *testing.T) {
+		t.Parallel()
+
+		r := strings.NewReader("# deploy\n\n1st\n\n# deploy\n\n2nd\n")
+
+		var buf bytes.Buffer
+		err := fcqs.WriteTitlesJSON(&buf, r, fcqs.ListOptions{})
+
+		require.NoError(t, err)
+		expected := `[
+			{"id": "ee348496ae49", "title": "deploy", "file": "", "line": 1},
+			{"id": "9754fee56548", "title": "deploy", "file": "", "line": 5}
+		]`
+		assert.JSONEq(t, expected, buf.String())
+	})
+
+	t.Run("contents by ID of duplicate title", func(t *testing.T) {
+		t.Parallel()
+
+		r := strings.NewReader("# deploy\n\n1st\n\n# deploy\n\n2nd\n")
+		id, err := value.NewID("9754fee56548")
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		err = fcqs.WriteContents(&buf, r, id, false)
+
+		require.NoError(t, err)
+		assert.Equal(t, "# deploy\n\n2nd\n", buf.String())
+	})
+
+	t.Run("stop at the note with ID", func(t *testing.T) {
+		t.Parallel()
+
+		notes := "# deploy {#deploy}\n\nmake deploy\n\n# other\n\nother\n"
+		r := io.MultiReader(strings.NewReader(notes), iotest.ErrReader(ErrScanForTest))
+		id, err := value.NewID("deploy")
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		err = fcqs.WriteContents(&buf, r, id, false)
+
+		require.NoError(t, err)
+		assert.Equal(t, "# deploy {#deploy}\n\nmake deploy\n", buf.String())
+	})
+}
+
+func TestWriteLinks(t *testing.T) {
+	// Cannot run tests in parallel due to fcqs.SetWarnWriter
+
+	tests := []struct {
+		title  string
+		policy value.MatchPolicy
+		links  string
+		warn   string
+	}{
+		{"deploy", value.MatchExact, "build image\nrollout status\n",
+			"warning: %[1]s:4: broken link: Build Image\nwarning: %[1]s:10: broken link: missing note\n"},
+		{"deploy", value.MatchCaseFold, "build image\nrollout status\n", "warning: %[1]s:10: broken link: missing note\n"},
+		{"rollout", value.MatchExact, "deploy\n", ""},
+		{"cleanup", value.MatchExact, "", ""},
+		{"unknown", value.MatchExact, "", ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.title, func(t *testing.T) {
+			var warn bytes.Buffer
+			fcqs.SetWarnWriter(t, &warn)
+
+			file := openTestNotesFile(t, test.LinksFile)
+			title, err := value.NewTitle(tc.title)
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			err = fcqs.WriteLinks(&buf, file, title.WithMatchPolicy(tc.policy))
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.links, buf.String())
+			if tc.warn == "" {
+				assert.Empty(t, warn.String())
+			} else {
+				assert.Equal(t, fmt.Sprintf(tc.warn, test.LinksFile), warn.String())
+			}
+		})
+	}
+}
+
+func TestWriteBacklinks(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		title     string
+		policy    value.MatchPolicy
+		backlinks string
+	}{
+		{"build image", value.MatchExact, "deploy\n"},
+		{"build image", value.MatchCaseFold, "deploy\n"},
+		{"rollout status", value.MatchExact, "deploy\n"},
+		{"deploy", value.MatchExact, "rollout status\n"},
+		{"cleanup", value.MatchExact, ""},
+		{"not a link", value.MatchExact, ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			file := openTestNotesFile(t, test.LinksFile)
+			title, err := value.NewTitle(tc.title)
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			err = fcqs.WriteBacklinks(&buf, file, title.WithMatchPolicy(tc.policy))
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.backlinks, buf.String())
+		})
+	}
+}
+
+func TestTransclusion(t *testing.T) {
+	// Cannot run tests in parallel due to fcqs.SetWarnWriter
+
+	tests := []struct {
+		title    string
+		contents string
+		warn     string
+	}{
+		{"vpn", "# vpn\n\nConnect to the VPN.\n\n```sh\nvpn connect\n```\n", ""},
+		{
+			"deploy",
+			"# deploy\n\nConnect to the VPN.\n\n```sh\nvpn connect\n```\n\n```sh\nauth login\n```\n\n" +
+				"Deploy the app.\n\n```sh\nmake deploy\n```\n\n```md\n![[vpn]]\n```\n",
+			"",
+		},
+		{"cycle a", "# cycle a\n\n![[cycle a]]\n", "warning: %s:38: transclusion cycle: cycle a\n"},
+		{
+			"missing", "# missing\n\n![[unknown note]]\n\nInline ![[vpn]] is not expanded.\n",
+			"warning: %s:42: transcluded note not found: unknown note\n",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.title, func(t *testing.T) {
+			var warn bytes.Buffer
+			fcqs.SetWarnWriter(t, &warn)
+
+			file := openTestNotesFile(t, test.TransclusionFile)
+			title, err := value.NewTitle(tc.title)
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			err = fcqs.WriteContents(&buf, file, title, false)
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.contents, buf.String())
+			if tc.warn == "" {
+				assert.Empty(t, warn.String())
+			} else {
+				assert.Equal(t, fmt.Sprintf(tc.warn, test.TransclusionFile), warn.String())
+			}
+		})
+	}
+
+	t.Run("without title", func(t *testing.T) {
+		file := openTestNotesFile(t, test.TransclusionFile)
+		title, err := value.NewTitle("auth login")
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		err = fcqs.WriteContents(&buf, file, title, true)
+
+		require.NoError(t, err)
+		assert.Equal(t, "Connect to the VPN.\n\n```sh\nvpn connect\n```\n\n```sh\nauth login\n```\n", buf.String())
+	})
+
+	t.Run("command line block", func(t *testing.T) {
+		file := openTestNotesFile(t, test.TransclusionFile)
+		title, err := value.NewTitle("deploy")
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		err = fcqs.WriteFirstCmdLineBlock(&buf, file, title)
+
+		require.NoError(t, err)
+		assert.Equal(t, "vpn connect\n", buf.String())
+	})
+
+	t.Run("max depth", func(t *testing.T) {
+		var warn bytes.Buffer
+		fcqs.SetWarnWriter(t, &warn)
+
+		var notes strings.Builder
+		for i := range 12 {
+			fmt.Fprintf(&notes, "# note%d\n\n![[note%d]]\n\n", i, i+1)
+		}
+		fmt.Fprint(&notes, "# note12\n\nend\n")
+		title, err := value.NewTitle("note0")
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		err = fcqs.WriteContents(&buf, strings.NewReader(notes.String()), title, false)
+
+		require.NoError(t, err)
+		assert.Equal(t, "# note0\n\n![[note9]]\n", buf.String())
+		assert.Equal(t, "warning: line 35: transclusion too deep: note9\n", warn.String())
+	})
+
+	t.Run("reader that cannot seek", func(t *testing.T) {
+		var warn bytes.Buffer
+		fcqs.SetWarnWriter(t, &warn)
+
+		notes := "# deploy\n\nbefore\n\n![[vpn]]\n\nafter\n\n# vpn\n\nvpn connect\n\n# unterminated\n\n```\n"
+		r := io.MultiReader(strings.NewReader(notes))
+		title, err := value.NewTitle("deploy")
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		err = fcqs.WriteContents(&buf, r, title, false)
+
+		require.NoError(t, err)
+		assert.Equal(t, "# deploy\n\nbefore\n\nvpn connect\n\nafter\n", buf.String())
+		assert.Equal(t, "warning: line 15: unterminated fenced code block\n", warn.String())
+	})
+}
+
+func TestInclude(t *testing.T) {
+	// Cannot run tests in parallel due to fcqs.SetWarnWriter
+
+	expectedWarn := fmt.Sprintf("warning: %[2]s:6: include cycle: %[3]s\n"+
+		"warning: %[1]s:13: include: open %[4]s: no such file or directory\n"+
+		"warning: %[1]s:14: include cycle: %[1]s\n",
+		test.IncludeFile, test.IncludedShared, test.IncludedFile, filepath.Join(filepath.Dir(test.IncludedFile), "missing.md"))
+
+	t.Run("titles", func(t *testing.T) {
+		var warn bytes.Buffer
+		fcqs.SetWarnWriter(t, &warn)
+
+		file := openTestNotesFile(t, test.IncludeFile)
+
+		var buf bytes.Buffer
+		err := fcqs.WriteTitles(&buf, file)
+
+		require.NoError(t, err)
+		assert.Equal(t, "entry\nk8s: pods\nshared\nafter include\n", buf.String())
+		assert.Equal(t, expectedWarn, warn.String())
+	})
+
+	tests := []struct {
+		title    string
+		contents string
+	}{
+		{"entry", "# entry\n\nEntry point of the notes.\n\nBack in the entry after the included files.\n"},
+		{"k8s: pods", "# pods\n\n```sh\nkubectl get pods\n```\n"},
+		{"shared", "# shared\n\nShared contents\n"},
+		{"after include", "# after include\n\nThis note is after the included files.\n"},
+	}
+	for _, tc := range tests {
+		t.Run("contents of "+tc.title, func(t *testing.T) {
+			fcqs.SetWarnWriter(t, io.Discard)
+
+			file := openTestNotesFile(t, test.IncludeFile)
+			title, err := value.NewTitle(tc.title)
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			err = fcqs.WriteContents(&buf, file, title, false)
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.contents, buf.String())
+		})
+	}
+
+	locations := []struct {
+		title    string
+		location string
+	}{
+		{"entry", fmt.Sprintf("%q 1\n", test.IncludeFile)},
+		{"k8s: pods", fmt.Sprintf("%q 5\n", test.IncludedFile)},
+		{"shared", fmt.Sprintf("%q 1\n", test.IncludedShared)},
+		{"after include", fmt.Sprintf("%q 9\n", test.IncludeFile)},
+	}
+	for _, tc := range locations {
+		t.Run("location of "+tc.title, func(t *testing.T) {
+			fcqs.SetWarnWriter(t, io.Discard)
+
+			file := openTestNotesFile(t, test.IncludeFile)
+			title, err := value.NewTitle(tc.title)
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			err = fcqs.WriteNoteLocation(&buf, []*os.File{file}, title)
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.location, buf.String())
+		})
+	}
+	t.Run("cycle through symbolic link", func(t *testing.T) {
+		var warn bytes.Buffer
+		fcqs.SetWarnWriter(t, &warn)
+
+		dir := t.TempDir()
+		fileName := filepath.Join(dir, "notes.md")
+		require.NoError(t, os.WriteFile(fileName, []byte("# notes\n\ncontents\n\n<!-- include: link.md -->\n"), 0o600))
+		require.NoError(t, os.Symlink(fileName, filepath.Join(dir, "link.md")))
+
+		file := openTestNotesFile(t, fileName)
+
+		var buf bytes.Buffer
+		err := fcqs.WriteTitles(&buf, file)
+
+		require.NoError(t, err)
+		assert.Equal(t, "notes\n", buf.String())
+		assert.Equal(t, fmt.Sprintf("warning: %s:5: include cycle: %s\n", fileName, filepath.Join(dir, "link.md")), warn.String())
+	})
+}
+
+func TestSeparateDuplicates(t *testing.T) {
+	t.Parallel()
+
+	t.Run("titles in the same file", func(t *testing.T) {
+		t.Parallel()
+
+		file := openTestNotesFile(t, test.NotesFile)
+
+		var buf bytes.Buffer
+		err := fcqs.WriteTitlesWithOptions(&buf, file, fcqs.ListOptions{Separate: true})
+
+		require.NoError(t, err)
+		expected := strings.Replace(test.ExpectedTitles, "same title\n",
+			"same title [test_fcnotes.md:24]\nsame title [test_fcnotes.md:28]\n", 1)
+		expected = strings.Replace(expected, "Trailing spaces in the title are ignored\n",
+			"Trailing spaces in the title are ignored\nsame title [test_fcnotes.md:40]\n", 1)
+		assert.Equal(t, expected, buf.String())
+	})
+
+	t.Run("contents", func(t *testing.T) {
+		t.Parallel()
+
+		tests := []struct {
+			title    string
+			contents string
+		}{
+			{"same title [test_fcnotes.md:28]", "# same title\n\n2nd\n"},
+			{"same title [test_fcnotes.md]", "# same title\n\nContents with the same title are combined into one.\n\n" +
+				"# same title\n\n2nd\n\n" + "# same title\n\n3rd\n"},
+			{"same title [test_fcnotes.md:29]", ""},
+			{"same title [other.md:28]", ""},
+		}
+		for _, tc := range tests {
+			t.Run(tc.title, func(t *testing.T) {
+				t.Parallel()
+
+				file := openTestNotesFile(t, test.NotesFile)
+				title, err := value.NewTitle(tc.title)
+				require.NoError(t, err)
+
+				var buf bytes.Buffer
+				err = fcqs.WriteContents(&buf, file, title, false)
+
+				require.NoError(t, err)
+				assert.Equal(t, tc.contents, buf.String())
+			})
+		}
+	})
+
+	t.Run("titles in reader without name", func(t *testing.T) {
+		t.Parallel()
+
+		r := strings.NewReader("# title\n\n1st\n\n# title\n\n2nd\n")
+
+		var buf bytes.Buffer
+		err := fcqs.WriteTitlesWithOptions(&buf, r, fcqs.ListOptions{Separate: true})
+
+		require.NoError(t, err)
+		assert.Equal(t, "title [:1]\ntitle [:5]\n", buf.String())
+	})
+
+	t.Run("location in multi files", func(t *testing.T) {
+		t.Parallel()
+
+		files := []*os.File{openTestNotesFile(t, test.LocationFile), openTestNotesFile(t, test.LocationExtraFile)}
+		title, err := value.NewTitle("location test data [test_location_extra.md]")
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		err = fcqs.WriteNoteLocation(&buf, files, title)
+
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("%q 1\n", test.LocationExtraFile), buf.String())
+	})
+}
+
+func TestSeparateDuplicatesInSameNamedFiles(t *testing.T) {
+	// Cannot run tests in parallel due to t.Setenv
+
+	var files []string
+	for _, contents := range []string{"# deploy\n\n1st\n", "# deploy\n\n2nd\n"} {
+		fileName := filepath.Join(t.TempDir(), fcqs.DefaultNotesFile)
+		err := os.WriteFile(fileName, []byte(contents), 0o600)
+		require.NoError(t, err)
+		files = append(files, fileName)
+	}
+
+	t.Setenv("FCQS_NOTES_FILE", test.MultiFiles(files...))
+	t.Setenv("FCQS_NOTES_FILES", "")
+
+	t.Run("titles", func(t *testing.T) {
+		notes, err := fcqs.OpenNotesFiles()
+		require.NoError(t, err)
+		defer notes.Close()
+
+		var buf bytes.Buffer
+		err = fcqs.WriteTitlesWithOptions(&buf, notes.Reader, fcqs.ListOptions{Separate: true})
+
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("deploy [%s]\ndeploy [%s]\n", files[0], files[1]), buf.String())
+	})
+
+	t.Run("contents", func(t *testing.T) {
+		notes, err := fcqs.OpenNotesFiles()
+		require.NoError(t, err)
+		defer notes.Close()
+
+		title, err := value.NewTitle(fmt.Sprintf("deploy [%s]", files[1]))
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		err = fcqs.WriteContents(&buf, notes.Reader, title, false)
+
+		require.NoError(t, err)
+		assert.Equal(t, "# deploy\n\n2nd\n", buf.String())
+	})
+}
+
+func TestUnterminatedFence(t *testing.T) {
+	// Cannot run tests in parallel due to fcqs.SetWarnWriter
+
+	t.Setenv("FCQS_NOTES_FILE", test.MultiFiles(test.UnterminatedFile, test.LocationFile))
+	t.Setenv("FCQS_NOTES_FILES", "")
+	expectedWarning := fmt.Sprintf("warning: %s:3: unterminated fenced code block\n", test.UnterminatedFile)
+
+	t.Run("titles", func(t *testing.T) {
+		var warn bytes.Buffer
+		fcqs.SetWarnWriter(t, &warn)
+
+		notes, err := fcqs.OpenNotesFiles()
+		require.NoError(t, err)
+		defer notes.Close()
+
+		var buf bytes.Buffer
+		err = fcqs.WriteTitles(&buf, notes.Reader)
+
+		require.NoError(t, err)
+		assert.Equal(t, "unterminated fence\nlocation test data\n5th Line\n", buf.String())
+		assert.Equal(t, expectedWarning, warn.String())
+	})
+
+	t.Run("contents", func(t *testing.T) {
+		var warn bytes.Buffer
+		fcqs.SetWarnWriter(t, &warn)
+
+		notes, err := fcqs.OpenNotesFiles()
+		require.NoError(t, err)
+		defer notes.Close()
+
+		title, err := value.NewTitle("5th Line")
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		err = fcqs.WriteContents(&buf, notes.Reader, title, false)
+
+		require.NoError(t, err)
+		assert.Equal(t, "# 5th Line\n\nDo not chang the 5th line.\n", buf.String())
+		assert.Equal(t, expectedWarning, warn.String())
+	})
+
+	t.Run("single reader", func(t *testing.T) {
+		var warn bytes.Buffer
+		fcqs.SetWarnWriter(t, &warn)
+
+		r := strings.NewReader("# title\n\n```\ncode\n")
+
+		var buf bytes.Buffer
+		err := fcqs.WriteTitles(&buf, r)
+
+		require.NoError(t, err)
+		assert.Equal(t, "title\n", buf.String())
+		assert.Equal(t, "warning: line 3: unterminated fenced code block\n", warn.String())
+	})
+}
+
 func BenchmarkWriteTitles(b *testing.B) {
 	file, err := os.Open(test.NotesFile)
 	require.NoError(b, err)
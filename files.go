@@ -40,11 +40,17 @@ func OpenNotesFiles() (*NotesFiles, error) {
 		files = append(files, file)
 	}
 
-	reader := io.MultiReader(readers...)
+	reader := &notesReader{Reader: io.MultiReader(readers...), files: files}
 
 	return &NotesFiles{Reader: reader, Files: files}, nil
 }
 
+// notesReader reads notes files sequentially and keeps them apart for scanning.
+type notesReader struct {
+	io.Reader
+	files []*os.File
+}
+
 // notesFileNames returns filenames of notes.
 func notesFileNames() ([]string, error) {
 	f := os.Getenv("FCQS_NOTES_FILES")
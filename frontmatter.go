@@ -0,0 +1,55 @@
+package fcqs
+
+import (
+	"strings"
+
+	"github.com/yendo/fcqs/internal/value"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	frontMatterDelimiter    = "---"
+	frontMatterEndDelimiter = "..."
+)
+
+// frontMatter represents the metadata of a notes file written in YAML front matter.
+type frontMatter struct {
+	Notebook string   `yaml:"notebook"`
+	Tags     []string `yaml:"tags"`
+	Shell    string   `yaml:"shell"`
+	Prefix   string   `yaml:"prefix"`
+}
+
+// displayTitle returns the title with the display prefix.
+func (fm *frontMatter) displayTitle(title string) string {
+	if fm == nil || fm.Prefix == "" {
+		return title
+	}
+
+	return strings.TrimRight(fm.Prefix, " ") + " " + title
+}
+
+// blockLang returns the language identifier of the fenced code block.
+// A code block without language identifier is in the default shell language.
+func (fm *frontMatter) blockLang(opening *value.FenceLine) string {
+	if fm != nil && fm.Shell != "" && opening.Lang() == "" {
+		return fm.Shell
+	}
+
+	return opening.Lang()
+}
+
+// isShellBlock reports whether the fenced code block is a shell block.
+func (fm *frontMatter) isShellBlock(opening *value.FenceLine) bool {
+	return value.IsShellID(fm.blockLang(opening))
+}
+
+// newFrontMatter returns the front matter parsed from the lines between the delimiters.
+func newFrontMatter(lines []string) (*frontMatter, error) {
+	var fm frontMatter
+	if err := yaml.Unmarshal([]byte(strings.Join(lines, "\n")), &fm); err != nil {
+		return nil, err
+	}
+
+	return &fm, nil
+}
@@ -0,0 +1,64 @@
+package fcqs
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// defaultHistoryFile is the history file in the state directory.
+const defaultHistoryFile = "fcqs/history.jsonl"
+
+// HistoryEntry represents a record of command lines run from a note.
+type HistoryEntry struct {
+	Time       time.Time `json:"time"`
+	ID         string    `json:"id"`
+	Title      string    `json:"title"`
+	Dir        string    `json:"dir,omitempty"`
+	ExitStatus int       `json:"exit_status"`
+}
+
+// AppendHistory appends the entry to the history file as a JSON line.
+// The history file is FCQS_HISTORY_FILE, or "fcqs/history.jsonl" in the XDG state directory.
+func AppendHistory(entry HistoryEntry) error {
+	fileName, err := historyFileName()
+	if err != nil {
+		return fmt.Errorf("history file name: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(fileName), 0o700); err != nil {
+		return fmt.Errorf("history file: %w", err)
+	}
+
+	file, err := os.OpenFile(fileName, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return fmt.Errorf("history file: %w", err)
+	}
+	defer file.Close()
+
+	if err := json.NewEncoder(file).Encode(entry); err != nil {
+		return fmt.Errorf("history file: %w", err)
+	}
+
+	return nil
+}
+
+// historyFileName returns the name of the history file.
+func historyFileName() (string, error) {
+	if f := os.Getenv("FCQS_HISTORY_FILE"); f != "" {
+		return f, nil
+	}
+
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, defaultHistoryFile), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("user home directory: %w", err)
+	}
+
+	return filepath.Join(home, ".local", "state", defaultHistoryFile), nil
+}
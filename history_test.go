@@ -0,0 +1,74 @@
+package fcqs_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yendo/fcqs"
+)
+
+func TestAppendHistory(t *testing.T) {
+	entry := fcqs.HistoryEntry{
+		Time:       time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		ID:         "7c5e6d8f0a1b",
+		Title:      "greet",
+		Dir:        "/tmp",
+		ExitStatus: 3,
+	}
+	expected := `{"time":"2024-01-02T03:04:05Z","id":"7c5e6d8f0a1b","title":"greet","dir":"/tmp","exit_status":3}` + "\n"
+
+	t.Run("history file from environment variable", func(t *testing.T) {
+		fileName := filepath.Join(t.TempDir(), "history", "fcqs.jsonl")
+		t.Setenv("FCQS_HISTORY_FILE", fileName)
+
+		for range 2 {
+			err := fcqs.AppendHistory(entry)
+			require.NoError(t, err)
+		}
+
+		data, err := os.ReadFile(fileName)
+		require.NoError(t, err)
+		assert.Equal(t, expected+expected, string(data))
+	})
+
+	t.Run("history file in state directory", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv("FCQS_HISTORY_FILE", "")
+		t.Setenv("XDG_STATE_HOME", dir)
+
+		err := fcqs.AppendHistory(entry)
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(filepath.Join(dir, "fcqs", "history.jsonl"))
+		require.NoError(t, err)
+		assert.Equal(t, expected, string(data))
+	})
+
+	t.Run("history file in home directory", func(t *testing.T) {
+		home := t.TempDir()
+		t.Setenv("FCQS_HISTORY_FILE", "")
+		t.Setenv("XDG_STATE_HOME", "")
+		t.Setenv("HOME", home)
+
+		err := fcqs.AppendHistory(entry)
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(filepath.Join(home, ".local", "state", "fcqs", "history.jsonl"))
+		require.NoError(t, err)
+		assert.Equal(t, expected, string(data))
+	})
+
+	t.Run("failed to access user home directory", func(t *testing.T) {
+		t.Setenv("FCQS_HISTORY_FILE", "")
+		t.Setenv("XDG_STATE_HOME", "")
+		t.Setenv("HOME", "")
+
+		err := fcqs.AppendHistory(entry)
+
+		require.EqualError(t, err, "history file name: user home directory: $HOME is not defined")
+	})
+}
@@ -0,0 +1,83 @@
+package clipboard
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+)
+
+const (
+	// osc52Format is the OSC 52 escape sequence to set the clipboard of the terminal.
+	// tmux handles it itself with set-clipboard, so it is not wrapped for passthrough.
+	osc52Format = "\x1b]52;c;%s\a"
+
+	ttyName = "/dev/tty"
+)
+
+// clipboardCommand represents a command to write to the clipboard from standard input.
+type clipboardCommand struct {
+	// env is the environment variable that must be set to use the command.
+	env  string
+	args []string
+}
+
+// commands are the clipboard commands in order of preference.
+var commands = []clipboardCommand{
+	{env: "WAYLAND_DISPLAY", args: []string{"wl-copy"}},
+	{env: "DISPLAY", args: []string{"xclip", "-selection", "clipboard"}},
+	{env: "DISPLAY", args: []string{"xsel", "--clipboard", "--input"}},
+	{args: []string{"pbcopy"}},
+}
+
+// openTTY opens the terminal to write escape sequences.
+var openTTY = func() (io.WriteCloser, error) {
+	return os.OpenFile(ttyName, os.O_WRONLY, 0)
+}
+
+// Write writes the data to the clipboard with a clipboard command such as wl-copy, xclip or xsel.
+// The data is written to the terminal with OSC 52 escape sequences if no command is available,
+// so that the clipboard is available over SSH and in tmux.
+func Write(data []byte) error {
+	if args := command(); args != nil {
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Stdin = bytes.NewReader(data)
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("copy: %s: %w", args[0], err)
+		}
+		return nil
+	}
+
+	tty, err := openTTY()
+	if err != nil {
+		return fmt.Errorf("copy: %w", err)
+	}
+	defer tty.Close()
+
+	if err := writeOSC52(tty, data); err != nil {
+		return fmt.Errorf("copy: %w", err)
+	}
+	return nil
+}
+
+// command returns the arguments of the first available clipboard command, or nil if there is none.
+func command() []string {
+	for _, c := range commands {
+		if c.env != "" && os.Getenv(c.env) == "" {
+			continue
+		}
+		if _, err := exec.LookPath(c.args[0]); err == nil {
+			return c.args
+		}
+	}
+
+	return nil
+}
+
+// writeOSC52 writes the OSC 52 escape sequence to set the data to the clipboard of the terminal.
+func writeOSC52(w io.Writer, data []byte) error {
+	_, err := fmt.Fprintf(w, osc52Format, base64.StdEncoding.EncodeToString(data))
+	return err
+}
@@ -0,0 +1,120 @@
+package clipboard_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yendo/fcqs/internal/clipboard"
+)
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// setFakeCommands sets PATH to only a directory with the fake clipboard commands,
+// which write their arguments and standard input to the "out" file in the directory.
+func setFakeCommands(t *testing.T, names ...string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for _, name := range names {
+		out := filepath.Join(dir, "out")
+		script := fmt.Sprintf("#!/bin/sh\nPATH=%q\necho \"%s $*\" > %q\ncat >> %q\n", os.Getenv("PATH"), name, out, out)
+		writeScript(t, filepath.Join(dir, name), script)
+	}
+
+	t.Setenv("PATH", dir)
+	t.Setenv("WAYLAND_DISPLAY", "")
+	t.Setenv("DISPLAY", "")
+	t.Setenv("TMUX", "")
+
+	return dir
+}
+
+func writeScript(t *testing.T, name, script string) {
+	t.Helper()
+
+	err := os.WriteFile(name, []byte(script), 0o755)
+	require.NoError(t, err)
+}
+
+func TestWriteWithCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		commands []string
+		env      string
+		expected string
+	}{
+		{"wl-copy on Wayland", []string{"wl-copy", "xclip"}, "WAYLAND_DISPLAY", "wl-copy \n"},
+		{"xclip on X", []string{"wl-copy", "xclip", "xsel"}, "DISPLAY", "xclip -selection clipboard\n"},
+		{"xsel on X", []string{"xsel"}, "DISPLAY", "xsel --clipboard --input\n"},
+		{"pbcopy", []string{"pbcopy"}, "", "pbcopy \n"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := setFakeCommands(t, tc.commands...)
+			if tc.env != "" {
+				t.Setenv(tc.env, "1")
+			}
+
+			err := clipboard.Write([]byte("ls -l\n"))
+			require.NoError(t, err)
+
+			data, err := os.ReadFile(filepath.Join(dir, "out"))
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected+"ls -l\n", string(data))
+		})
+	}
+
+	t.Run("command failed", func(t *testing.T) {
+		dir := setFakeCommands(t)
+		writeScript(t, filepath.Join(dir, "pbcopy"), "#!/bin/sh\nexit 1\n")
+
+		err := clipboard.Write([]byte("ls -l\n"))
+		require.EqualError(t, err, "copy: pbcopy: exit status 1")
+	})
+}
+
+func TestWriteWithOSC52(t *testing.T) {
+	t.Run("terminal", func(t *testing.T) {
+		setFakeCommands(t)
+		var buf bytes.Buffer
+		clipboard.SetOpenTTY(t, func() (io.WriteCloser, error) { return nopWriteCloser{&buf}, nil })
+
+		err := clipboard.Write([]byte("ls -l\n"))
+
+		require.NoError(t, err)
+		assert.Equal(t, "\x1b]52;c;bHMgLWwK\a", buf.String())
+	})
+
+	t.Run("tmux", func(t *testing.T) {
+		setFakeCommands(t)
+		t.Setenv("TMUX", "/tmp/tmux-1000/default,1,0")
+		var buf bytes.Buffer
+		clipboard.SetOpenTTY(t, func() (io.WriteCloser, error) { return nopWriteCloser{&buf}, nil })
+
+		err := clipboard.Write([]byte("ls -l\n"))
+
+		require.NoError(t, err)
+		assert.Equal(t, "\x1b]52;c;bHMgLWwK\a", buf.String())
+	})
+
+	t.Run("no terminal", func(t *testing.T) {
+		setFakeCommands(t)
+		clipboard.SetOpenTTY(t, func() (io.WriteCloser, error) { return nil, errors.New("no tty") })
+
+		err := clipboard.Write([]byte("ls -l\n"))
+
+		require.EqualError(t, err, "copy: no tty")
+	})
+}
@@ -0,0 +1,17 @@
+package clipboard
+
+import (
+	"io"
+	"testing"
+)
+
+func SetOpenTTY(t *testing.T, f func() (io.WriteCloser, error)) {
+	t.Helper()
+
+	tmp := openTTY
+	openTTY = f
+
+	t.Cleanup(func() {
+		openTTY = tmp
+	})
+}
@@ -0,0 +1,85 @@
+package render
+
+import (
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+)
+
+// ANSI colors for syntax highlighting.
+const (
+	sgrGreen   = "\x1b[32m"
+	sgrYellow  = "\x1b[33m"
+	sgrBlue    = "\x1b[34m"
+	sgrMagenta = "\x1b[35m"
+	sgrGray    = "\x1b[90m"
+)
+
+// segment represents a text segment styled with the SGR sequence.
+type segment struct {
+	text string
+	sgr  string
+}
+
+// plainSegments returns the lines of the code as segments without styles.
+func plainSegments(code []string) [][]segment {
+	lines := make([][]segment, 0, len(code))
+	for _, line := range code {
+		lines = append(lines, []segment{{text: line}})
+	}
+
+	return lines
+}
+
+// highlight returns the lines of the code split into the segments styled for the language.
+// The segments are not styled if the language is not supported.
+func highlight(lang string, code []string) [][]segment {
+	lexer := lexers.Get(lang)
+	if lang == "" || lexer == nil {
+		return plainSegments(code)
+	}
+
+	iterator, err := chroma.Coalesce(lexer).Tokenise(nil, strings.Join(code, "\n")+"\n")
+	if err != nil {
+		return plainSegments(code)
+	}
+
+	lines := make([][]segment, 0, len(code))
+	for _, tokens := range chroma.SplitTokensIntoLines(iterator.Tokens()) {
+		var segments []segment
+		for _, token := range tokens {
+			if text := strings.TrimSuffix(token.Value, "\n"); text != "" {
+				segments = append(segments, segment{text: text, sgr: tokenSGR(token.Type)})
+			}
+		}
+		lines = append(lines, segments)
+	}
+
+	// The lexer may not keep the lines of the code.
+	if len(lines) != len(code) {
+		return plainSegments(code)
+	}
+
+	return lines
+}
+
+// tokenSGR returns the SGR sequence to style the token type.
+func tokenSGR(t chroma.TokenType) string {
+	switch {
+	case t.InCategory(chroma.Comment):
+		return sgrGray
+	case t.InCategory(chroma.Keyword):
+		return sgrMagenta
+	case t.InSubCategory(chroma.LiteralString):
+		return sgrGreen
+	case t.InSubCategory(chroma.LiteralNumber):
+		return sgrYellow
+	case t.InSubCategory(chroma.NameBuiltin), t == chroma.NameFunction, t == chroma.NameTag:
+		return sgrBlue
+	case t.InSubCategory(chroma.NameVariable):
+		return sgrCyan
+	default:
+		return ""
+	}
+}
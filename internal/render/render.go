@@ -0,0 +1,267 @@
+package render
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/yendo/fcqs/internal/value"
+	"golang.org/x/text/width"
+	"mvdan.cc/xurls/v2"
+)
+
+// DefaultWidth is the width of the terminal if unknown.
+const DefaultWidth = 80
+
+// ANSI escape sequences.
+const (
+	sgrReset = "\x1b[0m"
+	sgrBold  = "\x1b[1m"
+	sgrFaint = "\x1b[2m"
+	sgrUnder = "\x1b[4m"
+	sgrCyan  = "\x1b[36m"
+	sgrAlert = "\x1b[1;31m"
+
+	hyperlinkFormat = "\x1b]8;;%s\x1b\\%s\x1b]8;;\x1b\\"
+
+	warningSign = "⚠ "
+)
+
+// Box drawing characters for fenced code blocks.
+const (
+	boxHorizontal  = "─"
+	boxVertical    = "│"
+	boxTopLeft     = "┌"
+	boxTopRight    = "┐"
+	boxBottomLeft  = "└"
+	boxBottomRight = "┘"
+
+	// boxPadding is the width of the borders and the spaces around the code.
+	boxPadding = 4
+
+	tabSpaces = "    "
+)
+
+var inlineCodePattern = regexp.MustCompile("`[^`]+`")
+
+// Options represents options to render markdown text.
+type Options struct {
+	// Color enables ANSI colors and styles. It should be disabled if NO_COLOR is set.
+	Color bool
+
+	// Width is the width of the terminal.
+	Width int
+
+	// Warnings are shown above the text, such as dangerous commands in the text.
+	Warnings []string
+}
+
+// renderer renders markdown text for terminals.
+type renderer struct {
+	w    io.Writer
+	opts Options
+}
+
+// Write writes the markdown text rendered with ANSI escape sequences for terminals.
+func Write(w io.Writer, r io.Reader, opts Options) error {
+	var lines []string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("render: %w", err)
+	}
+
+	if opts.Width <= 0 {
+		opts.Width = DefaultWidth
+	}
+	rd := &renderer{w: w, opts: opts}
+
+	for _, warning := range opts.Warnings {
+		fmt.Fprintln(rd.w, rd.style(sgrAlert, warningSign+warning))
+	}
+	if len(opts.Warnings) > 0 {
+		fmt.Fprintln(rd.w)
+	}
+
+	isParagraph := false
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if opening, ok := value.NewFenceLine(line); ok {
+			i = rd.writeCodeBlock(opening, lines, i+1)
+			isParagraph = false
+			continue
+		}
+
+		if tl, ok := value.NewTitleLine(line); ok {
+			rd.writeHeading(tl)
+			isParagraph = false
+			continue
+		}
+
+		// A setext heading is a line of a single-line paragraph followed by an underline.
+		if !isParagraph && i+1 < len(lines) {
+			if tl, ok := value.NewSetextTitleLine(line, lines[i+1]); ok {
+				rd.writeHeading(tl)
+				i++
+				continue
+			}
+		}
+
+		rd.writeText(line)
+		isParagraph = strings.TrimSpace(line) != ""
+	}
+
+	return nil
+}
+
+// style returns the text with the SGR sequence if colors are enabled.
+func (rd *renderer) style(sgr, text string) string {
+	if !rd.opts.Color || sgr == "" || text == "" {
+		return text
+	}
+
+	return sgr + text + sgrReset
+}
+
+// writeHeading writes the title of the heading in bold.
+func (rd *renderer) writeHeading(tl *value.TitleLine) {
+	if !tl.HasValidTitle() {
+		fmt.Fprintln(rd.w)
+		return
+	}
+
+	fmt.Fprintln(rd.w, rd.style(sgrBold, tl.Title().String()))
+}
+
+// writeText writes the text line with highlighted inline code and hyperlinks of URLs.
+func (rd *renderer) writeText(line string) {
+	var sb strings.Builder
+
+	start := 0
+	for _, loc := range inlineCodePattern.FindAllStringIndex(line, -1) {
+		sb.WriteString(rd.linkURLs(line[start:loc[0]]))
+		code := line[loc[0]:loc[1]]
+		if rd.opts.Color {
+			code = rd.style(sgrCyan, strings.Trim(code, "`"))
+		}
+		sb.WriteString(code)
+		start = loc[1]
+	}
+	sb.WriteString(rd.linkURLs(line[start:]))
+
+	fmt.Fprintln(rd.w, sb.String())
+}
+
+// linkURLs returns the text with the URLs as OSC 8 hyperlinks.
+func (rd *renderer) linkURLs(text string) string {
+	return xurls.Strict().ReplaceAllStringFunc(text, func(url string) string {
+		return fmt.Sprintf(hyperlinkFormat, url, rd.style(sgrUnder, url))
+	})
+}
+
+// writeCodeBlock writes the fenced code block from the start line in a box,
+// and returns the index of the closing fence line.
+func (rd *renderer) writeCodeBlock(opening *value.FenceLine, lines []string, start int) int {
+	end := start
+	for end < len(lines) {
+		if fl, ok := value.NewFenceLine(lines[end]); ok && fl.Closes(opening) {
+			break
+		}
+		end++
+	}
+
+	code := make([]string, 0, end-start)
+	for _, line := range lines[start:end] {
+		code = append(code, strings.ReplaceAll(line, "\t", tabSpaces))
+	}
+
+	// The box fits in the terminal and has space for the language identifier.
+	label := opening.Lang()
+	inner := displayWidth(label) + 2
+	for _, line := range code {
+		inner = max(inner, displayWidth(line))
+	}
+	inner = max(min(inner, rd.opts.Width-boxPadding), 1)
+
+	top := boxHorizontal + strings.Repeat(boxHorizontal, inner+1)
+	if label != "" && displayWidth(label)+2 <= inner {
+		top = boxHorizontal + " " + label + " " + strings.Repeat(boxHorizontal, inner-displayWidth(label)-1)
+	}
+	fmt.Fprintln(rd.w, rd.style(sgrFaint, boxTopLeft+top+boxTopRight))
+
+	segments := plainSegments(code)
+	if rd.opts.Color {
+		segments = highlight(label, code)
+	}
+	for _, line := range segments {
+		for _, chunk := range wrap(line, inner) {
+			var sb strings.Builder
+			w := 0
+			for _, seg := range chunk {
+				sb.WriteString(rd.style(seg.sgr, seg.text))
+				w += displayWidth(seg.text)
+			}
+			padding := strings.Repeat(" ", inner-w)
+			fmt.Fprintln(rd.w, rd.style(sgrFaint, boxVertical)+" "+sb.String()+padding+" "+rd.style(sgrFaint, boxVertical))
+		}
+	}
+
+	fmt.Fprintln(rd.w, rd.style(sgrFaint, boxBottomLeft+strings.Repeat(boxHorizontal, inner+2)+boxBottomRight))
+
+	return end
+}
+
+// wrap splits the segments of the line into chunks within the width.
+func wrap(line []segment, w int) [][]segment {
+	var chunks [][]segment
+	var chunk []segment
+	chunkWidth := 0
+
+	for _, seg := range line {
+		var sb strings.Builder
+		for _, r := range seg.text {
+			rw := runeWidth(r)
+			if chunkWidth+rw > w && chunkWidth > 0 {
+				if sb.Len() > 0 {
+					chunk = append(chunk, segment{text: sb.String(), sgr: seg.sgr})
+					sb.Reset()
+				}
+				chunks = append(chunks, chunk)
+				chunk, chunkWidth = nil, 0
+			}
+			sb.WriteRune(r)
+			chunkWidth += rw
+		}
+		if sb.Len() > 0 {
+			chunk = append(chunk, segment{text: sb.String(), sgr: seg.sgr})
+		}
+	}
+
+	return append(chunks, chunk)
+}
+
+// displayWidth returns the width of the text displayed in terminals.
+func displayWidth(text string) int {
+	w := 0
+	for _, r := range text {
+		w += runeWidth(r)
+	}
+
+	return w
+}
+
+// runeWidth returns the width of the rune displayed in terminals.
+func runeWidth(r rune) int {
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	default:
+		return 1
+	}
+}
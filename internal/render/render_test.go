@@ -0,0 +1,166 @@
+package render_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yendo/fcqs/internal/render"
+)
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		text     string
+		opts     render.Options
+		expected string
+	}{
+		{
+			name:     "ATX heading",
+			text:     "# title {#id}\n\ncontents\n",
+			opts:     render.Options{Color: true},
+			expected: "\x1b[1mtitle\x1b[0m\n\ncontents\n",
+		},
+		{
+			name:     "setext heading",
+			text:     "title\n=====\n\ncontents\nmore contents\n---\n",
+			opts:     render.Options{Color: true},
+			expected: "\x1b[1mtitle\x1b[0m\n\ncontents\nmore contents\n---\n",
+		},
+		{
+			name:     "heading without color",
+			text:     "# title\n",
+			opts:     render.Options{Color: false},
+			expected: "title\n",
+		},
+		{
+			name:     "inline code",
+			text:     "run `ls -l` and `pwd`\n",
+			opts:     render.Options{Color: true},
+			expected: "run \x1b[36mls -l\x1b[0m and \x1b[36mpwd\x1b[0m\n",
+		},
+		{
+			name:     "inline code without color",
+			text:     "run `ls -l`\n",
+			opts:     render.Options{Color: false},
+			expected: "run `ls -l`\n",
+		},
+		{
+			name:     "hyperlink",
+			text:     "see https://example.com/ for details\n",
+			opts:     render.Options{Color: true},
+			expected: "see \x1b]8;;https://example.com/\x1b\\\x1b[4mhttps://example.com/\x1b[0m\x1b]8;;\x1b\\ for details\n",
+		},
+		{
+			name:     "hyperlink without color",
+			text:     "https://example.com/\n",
+			opts:     render.Options{Color: false},
+			expected: "\x1b]8;;https://example.com/\x1b\\https://example.com/\x1b]8;;\x1b\\\n",
+		},
+		{
+			name:     "URL in inline code",
+			text:     "`curl https://example.com/`\n",
+			opts:     render.Options{Color: false},
+			expected: "`curl https://example.com/`\n",
+		},
+		{
+			name:     "fenced code block",
+			text:     "```sh\nls -l\n# not heading\n```\n",
+			opts:     render.Options{Color: false, Width: 80},
+			expected: "┌─ sh ──────────┐\n│ ls -l         │\n│ # not heading │\n└───────────────┘\n",
+		},
+		{
+			name:     "fenced code block without language",
+			text:     "~~~\nls\n~~~\n",
+			opts:     render.Options{Color: false, Width: 80},
+			expected: "┌────┐\n│ ls │\n└────┘\n",
+		},
+		{
+			name:     "fenced code block with color",
+			text:     "```\nls\n```\n",
+			opts:     render.Options{Color: true, Width: 80},
+			expected: "\x1b[2m┌────┐\x1b[0m\n\x1b[2m│\x1b[0m ls \x1b[2m│\x1b[0m\n\x1b[2m└────┘\x1b[0m\n",
+		},
+		{
+			name: "highlighted fenced code block",
+			text: "```yaml\nkey: \"value\" # comment\nn: 1\n```\n",
+			opts: render.Options{Color: true, Width: 80},
+			expected: "\x1b[2m┌─ yaml ─────────────────┐\x1b[0m\n" +
+				"\x1b[2m│\x1b[0m \x1b[34mkey\x1b[0m: \x1b[32m\"value\"\x1b[0m \x1b[90m# comment\x1b[0m \x1b[2m│\x1b[0m\n" +
+				"\x1b[2m│\x1b[0m \x1b[34mn\x1b[0m: \x1b[33m1\x1b[0m                   \x1b[2m│\x1b[0m\n" +
+				"\x1b[2m└────────────────────────┘\x1b[0m\n",
+		},
+		{
+			name: "wrapped highlighted fenced code block",
+			text: "```sh\necho \"abc\"\n```\n",
+			opts: render.Options{Color: true, Width: 12},
+			expected: "\x1b[2m┌─ sh ─────┐\x1b[0m\n" +
+				"\x1b[2m│\x1b[0m \x1b[34mecho\x1b[0m \x1b[32m\"ab\x1b[0m \x1b[2m│\x1b[0m\n" +
+				"\x1b[2m│\x1b[0m \x1b[32mc\"\x1b[0m       \x1b[2m│\x1b[0m\n" +
+				"\x1b[2m└──────────┘\x1b[0m\n",
+		},
+		{
+			name:     "fenced code block of unknown language",
+			text:     "```unknown-lang\nkey: 1\n```\n",
+			opts:     render.Options{Color: true, Width: 80},
+			expected: "\x1b[2m┌─ unknown-lang ─┐\x1b[0m\n\x1b[2m│\x1b[0m key: 1         \x1b[2m│\x1b[0m\n\x1b[2m└────────────────┘\x1b[0m\n",
+		},
+		{
+			name:     "fenced code block not highlighted without color",
+			text:     "```yaml\nn: 1\n```\n",
+			opts:     render.Options{Color: false, Width: 80},
+			expected: "┌─ yaml ─┐\n│ n: 1   │\n└────────┘\n",
+		},
+		{
+			name:     "wrapped fenced code block",
+			text:     "```\n1234567890\n```\n",
+			opts:     render.Options{Color: false, Width: 10},
+			expected: "┌────────┐\n│ 123456 │\n│ 7890   │\n└────────┘\n",
+		},
+		{
+			name:     "wide characters",
+			text:     "```\n日本語\n```\n",
+			opts:     render.Options{Color: false, Width: 80},
+			expected: "┌────────┐\n│ 日本語 │\n└────────┘\n",
+		},
+		{
+			name:     "warnings",
+			text:     "# title\n",
+			opts:     render.Options{Color: true, Warnings: []string{"first", "second"}},
+			expected: "\x1b[1;31m⚠ first\x1b[0m\n\x1b[1;31m⚠ second\x1b[0m\n\n\x1b[1mtitle\x1b[0m\n",
+		},
+		{
+			name:     "unterminated fenced code block",
+			text:     "```\nls\n",
+			opts:     render.Options{Color: false, Width: 80},
+			expected: "┌────┐\n│ ls │\n└────┘\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			err := render.Write(&buf, strings.NewReader(tc.text), tc.opts)
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, buf.String())
+		})
+	}
+}
+
+func TestWriteReadError(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	err := render.Write(&buf, iotest.ErrReader(iotest.ErrTimeout), render.Options{})
+
+	require.EqualError(t, err, "render: timeout")
+	assert.Empty(t, buf.String())
+}
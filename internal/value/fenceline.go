@@ -5,7 +5,13 @@ import (
 	"strings"
 )
 
-const codeFence = "```"
+const (
+	backtickFenceChar = '`'
+	tildeFenceChar    = '~'
+
+	// A fence needs at least three fence chars.
+	minFenceLength = 3
+)
 
 var shellList = []string{
 	"shell", "sh", "shell-script", "bash", "zsh",
@@ -13,32 +19,80 @@ var shellList = []string{
 	"shellsession", "console",
 }
 
+// sessionList is the shell languages of sessions with prompts and outputs.
+var sessionList = []string{"shellsession", "console"}
+
 // FenceLine represents a fence text line.
 type FenceLine struct {
-	line string
+	char   byte
+	length int
+	info   string
+}
+
+// Info returns the info string of the fence line.
+func (fl FenceLine) Info() string {
+	return fl.info
+}
+
+// Lang returns the language identifier which is the first word of the info string.
+func (fl FenceLine) Lang() string {
+	id := strings.Fields(fl.info)
+	if len(id) == 0 {
+		return ""
+	}
+
+	return id[0]
 }
 
 // HasShellID reports whether the fence line has shell identifier.
 func (fl FenceLine) HasShellID() bool {
-	trimmedLine := strings.Trim(fl.line, "` ")
-	if trimmedLine == "" {
-		return false
-	}
+	return IsShellID(fl.Lang())
+}
 
-	id := strings.Split(trimmedLine, " ")
-	return slices.Contains(shellList, id[0])
+// Closes reports whether the fence line closes the code block opened by the opening fence line.
+func (fl FenceLine) Closes(opening *FenceLine) bool {
+	return fl.char == opening.char && fl.length >= opening.length && fl.info == ""
 }
 
 // NewFenceLine returns Fence line.
 func NewFenceLine(line string) (*FenceLine, bool) {
-	if !IsFenceLine(line) {
+	trimmedLine := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmedLine) > maxIndent || trimmedLine == "" {
+		return nil, false
+	}
+
+	char := trimmedLine[0]
+	if char != backtickFenceChar && char != tildeFenceChar {
+		return nil, false
+	}
+
+	info := strings.TrimLeft(trimmedLine, string(char))
+	length := len(trimmedLine) - len(info)
+	if length < minFenceLength {
+		return nil, false
+	}
+
+	// The info string of a backtick fence must not contain backticks.
+	info = strings.Trim(info, " \t")
+	if char == backtickFenceChar && strings.ContainsRune(info, backtickFenceChar) {
 		return nil, false
 	}
 
-	return &FenceLine{line: line}, true
+	return &FenceLine{char: char, length: length, info: info}, true
+}
+
+// IsShellID reports whether the language identifier is for shell.
+func IsShellID(id string) bool {
+	return slices.Contains(shellList, id)
+}
+
+// IsSessionID reports whether the language identifier is for shell sessions with prompts and outputs.
+func IsSessionID(id string) bool {
+	return slices.Contains(sessionList, id)
 }
 
 // IsFenceLine reports whether the line is fence line.
 func IsFenceLine(line string) bool {
-	return strings.HasPrefix(line, codeFence)
+	_, ok := NewFenceLine(line)
+	return ok
 }
@@ -29,6 +29,9 @@ func TestFenceLineHasShellID(t *testing.T) {
 		{name: "pwsh", line: "``` pwsh", expect: true},
 		{name: "shellsession", line: "``` shellsession", expect: true},
 		{name: "console", line: "``` console", expect: true},
+		{name: "tilde", line: "~~~ sh", expect: true},
+		{name: "long fence", line: "````sh", expect: true},
+		{name: "tab after identifier", line: "```sh\t", expect: true},
 		{name: "go", line: "``` go", expect: false},
 		{name: "no identifier", line: "```", expect: false},
 		{name: "other identifier", line: "``` other", expect: false},
@@ -45,6 +48,23 @@ func TestFenceLineHasShellID(t *testing.T) {
 	}
 }
 
+func TestIsShellID(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, value.IsShellID("bash"))
+	assert.False(t, value.IsShellID("go"))
+	assert.False(t, value.IsShellID(""))
+}
+
+func TestIsSessionID(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, value.IsSessionID("console"))
+	assert.True(t, value.IsSessionID("shellsession"))
+	assert.False(t, value.IsSessionID("bash"))
+	assert.False(t, value.IsSessionID(""))
+}
+
 func TestFenceLineFuncs(t *testing.T) {
 	t.Parallel()
 
@@ -59,7 +79,16 @@ func TestFenceLineFuncs(t *testing.T) {
 		{name: "with long identifier", line: "``` shell console", expect: true},
 		{name: "no fence", line: "no fence", expect: false},
 		{name: "no enough fence", line: "``", expect: false},
-		{name: "head with spaces", line: " ```", expect: false},
+		{name: "head with spaces", line: "   ```", expect: true},
+		{name: "head with too many spaces", line: "    ```", expect: false},
+		{name: "tildes", line: "~~~", expect: true},
+		{name: "tildes with identifier", line: "~~~ go", expect: true},
+		{name: "long fence", line: "`````", expect: true},
+		{name: "no enough tildes", line: "~~", expect: false},
+		{name: "mixed fence chars", line: "``~", expect: false},
+		{name: "backtick in info string", line: "``` go`", expect: false},
+		{name: "backtick in tilde info string", line: "~~~ go`", expect: true},
+		{name: "inline code", line: "```code```", expect: false},
 		{name: "empty", line: "", expect: false},
 	}
 
@@ -91,3 +120,62 @@ func TestFenceLineFuncs(t *testing.T) {
 		}
 	})
 }
+
+func TestFenceLineInfo(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		line string
+		info string
+		lang string
+	}{
+		{name: "no info", line: "```", info: "", lang: ""},
+		{name: "language", line: "```go", info: "go", lang: "go"},
+		{name: "language with spaces", line: "```  go  ", info: "go", lang: "go"},
+		{name: "language and attributes", line: "~~~ sh title=\"x\"", info: "sh title=\"x\"", lang: "sh"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fenceLine, ok := value.NewFenceLine(tc.line)
+
+			require.True(t, ok)
+			assert.Equal(t, tc.info, fenceLine.Info())
+			assert.Equal(t, tc.lang, fenceLine.Lang())
+		})
+	}
+}
+
+func TestFenceLineCloses(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		opening string
+		closing string
+		expect  bool
+	}{
+		{name: "same fence", opening: "```", closing: "```", expect: true},
+		{name: "opening with identifier", opening: "```sh", closing: "```", expect: true},
+		{name: "tildes", opening: "~~~", closing: "~~~", expect: true},
+		{name: "longer closing fence", opening: "```", closing: "`````", expect: true},
+		{name: "indented closing fence", opening: "```", closing: "   ```  ", expect: true},
+		{name: "shorter closing fence", opening: "````", closing: "```", expect: false},
+		{name: "other fence char", opening: "```", closing: "~~~", expect: false},
+		{name: "closing with identifier", opening: "```", closing: "```sh", expect: false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			opening, ok := value.NewFenceLine(tc.opening)
+			require.True(t, ok)
+			closing, ok := value.NewFenceLine(tc.closing)
+			require.True(t, ok)
+
+			assert.Equal(t, tc.expect, closing.Closes(opening))
+		})
+	}
+}
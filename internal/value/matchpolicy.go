@@ -0,0 +1,73 @@
+package value
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// MatchPolicy represents a policy to match titles.
+// Policies can be combined.
+type MatchPolicy uint8
+
+// MatchExact matches the titles exactly.
+const MatchExact MatchPolicy = 0
+
+const (
+	// MatchCaseFold matches the titles ignoring cases.
+	MatchCaseFold MatchPolicy = 1 << iota
+
+	// MatchUnicode matches the titles normalized in Unicode NFC.
+	MatchUnicode
+
+	// MatchSpace matches the titles collapsing white spaces.
+	MatchSpace
+)
+
+var ErrInvalidMatchPolicy = errors.New("invalid match policy")
+
+var matchPolicyNames = map[string]MatchPolicy{
+	"exact":   MatchExact,
+	"case":    MatchCaseFold,
+	"unicode": MatchUnicode,
+	"space":   MatchSpace,
+}
+
+// Key returns the string to compare titles under the policy.
+func (p MatchPolicy) Key(s string) string {
+	if p&MatchUnicode != 0 {
+		s = norm.NFC.String(s)
+	}
+	if p&MatchCaseFold != 0 {
+		s = cases.Fold().String(s)
+	}
+	if p&MatchSpace != 0 {
+		s = strings.Join(strings.Fields(s), " ")
+	}
+
+	return s
+}
+
+// ParseMatchPolicy returns the match policy of the comma separated names,
+// "exact", "case", "unicode" and "space".
+func ParseMatchPolicy(s string) (MatchPolicy, error) {
+	var policy MatchPolicy
+
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		p, ok := matchPolicyNames[name]
+		if !ok {
+			return MatchExact, fmt.Errorf("%w: %s", ErrInvalidMatchPolicy, name)
+		}
+		policy |= p
+	}
+
+	return policy, nil
+}
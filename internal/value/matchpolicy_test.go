@@ -0,0 +1,74 @@
+package value_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yendo/fcqs/internal/value"
+)
+
+func TestParseMatchPolicy(t *testing.T) {
+	t.Parallel()
+
+	t.Run("success cases", func(t *testing.T) {
+		t.Parallel()
+
+		tests := []struct {
+			name   string
+			policy string
+			expect value.MatchPolicy
+		}{
+			{name: "empty", policy: "", expect: value.MatchExact},
+			{name: "exact", policy: "exact", expect: value.MatchExact},
+			{name: "case", policy: "case", expect: value.MatchCaseFold},
+			{name: "unicode", policy: "unicode", expect: value.MatchUnicode},
+			{name: "space", policy: "space", expect: value.MatchSpace},
+			{name: "combined", policy: "case, unicode,space", expect: value.MatchCaseFold | value.MatchUnicode | value.MatchSpace},
+		}
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				policy, err := value.ParseMatchPolicy(tc.policy)
+
+				require.NoError(t, err)
+				assert.Equal(t, tc.expect, policy)
+			})
+		}
+	})
+
+	t.Run("fail case", func(t *testing.T) {
+		t.Parallel()
+
+		policy, err := value.ParseMatchPolicy("case,other")
+
+		require.ErrorIs(t, err, value.ErrInvalidMatchPolicy)
+		require.EqualError(t, err, "invalid match policy: other")
+		assert.Equal(t, value.MatchExact, policy)
+	})
+}
+
+func TestMatchPolicyKey(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		policy value.MatchPolicy
+		s      string
+		expect string
+	}{
+		{name: "exact", policy: value.MatchExact, s: "Git  Rebase", expect: "Git  Rebase"},
+		{name: "case", policy: value.MatchCaseFold, s: "Git  Rebase", expect: "git  rebase"},
+		{name: "unicode", policy: value.MatchUnicode, s: "が", expect: "が"},
+		{name: "space", policy: value.MatchSpace, s: "Git \t Rebase", expect: "Git Rebase"},
+		{name: "all", policy: value.MatchCaseFold | value.MatchUnicode | value.MatchSpace, s: "Git  Rebase が", expect: "git rebase が"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expect, tc.policy.Key(tc.s))
+		})
+	}
+}
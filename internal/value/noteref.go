@@ -0,0 +1,38 @@
+package value
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrEmptyID = errors.New("ID is empty")
+
+// NoteRef represents a reference to a note by its title or its ID.
+type NoteRef interface {
+	String() string
+	noteRef()
+}
+
+// ID represents the stable ID of a note.
+type ID struct {
+	value string
+}
+
+// String returns an ID string.
+func (id ID) String() string {
+	return id.value
+}
+
+func (ID) noteRef() {}
+
+func (Title) noteRef() {}
+
+// NewID returns the ID to find a note.
+func NewID(id string) (*ID, error) {
+	id = strings.Trim(id, " ")
+	if id == "" {
+		return nil, ErrEmptyID
+	}
+
+	return &ID{value: id}, nil
+}
@@ -0,0 +1,31 @@
+package value_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yendo/fcqs/internal/value"
+)
+
+func TestNewID(t *testing.T) {
+	t.Parallel()
+
+	t.Run("success", func(t *testing.T) {
+		t.Parallel()
+
+		id, err := value.NewID(" note-id ")
+
+		require.NoError(t, err)
+		assert.Equal(t, "note-id", id.String())
+	})
+
+	t.Run("empty ID", func(t *testing.T) {
+		t.Parallel()
+
+		id, err := value.NewID("  ")
+
+		require.ErrorIs(t, err, value.ErrEmptyID)
+		assert.Nil(t, id)
+	})
+}
@@ -5,11 +5,17 @@ import (
 	"strings"
 )
 
+const (
+	locatorStart = " ["
+	locatorEnd   = "]"
+)
+
 var ErrEmptyTitle = errors.New("title is empty")
 
 // Title represents a title that does not allow empty titles.
 type Title struct {
-	value string
+	value  string
+	policy MatchPolicy
 }
 
 // String returns a title string.
@@ -18,8 +24,42 @@ func (t Title) String() string {
 }
 
 // Equal reports whether the title equals to other title.
+// The titles are compared under the match policies of both titles.
 func (t Title) Equals(other *Title) bool {
-	return t.String() == other.String()
+	p := t.policy | other.policy
+	return p.Key(t.String()) == p.Key(other.String())
+}
+
+// MatchPolicy returns the match policy of the title.
+func (t Title) MatchPolicy() MatchPolicy {
+	return t.policy
+}
+
+// WithMatchPolicy returns the title matched under the policy.
+func (t Title) WithMatchPolicy(p MatchPolicy) *Title {
+	t.policy = p
+	return &t
+}
+
+// SplitLocator splits the title like "title [locator]" into the title and the locator.
+func (t Title) SplitLocator() (*Title, string, bool) {
+	i := strings.LastIndex(t.value, locatorStart)
+	if i < 0 || !strings.HasSuffix(t.value, locatorEnd) {
+		return nil, "", false
+	}
+
+	title := strings.Trim(t.value[:i], " ")
+	locator := t.value[i+len(locatorStart) : len(t.value)-len(locatorEnd)]
+	if title == "" || locator == "" {
+		return nil, "", false
+	}
+
+	return &Title{value: title, policy: t.policy}, locator, true
+}
+
+// JoinLocator returns the title with the locator like "title [locator]".
+func JoinLocator(title, locator string) string {
+	return title + locatorStart + locator + locatorEnd
 }
 
 // NewTitleLine returns title.
@@ -1,6 +1,7 @@
 package value_test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -72,3 +73,102 @@ func TestTitleEquals(t *testing.T) {
 	assert.True(t, title1.Equals(title2))
 	assert.False(t, title1.Equals(otherTitle))
 }
+
+func TestTitleEqualsWithMatchPolicy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		title  string
+		other  string
+		policy value.MatchPolicy
+		expect bool
+	}{
+		{name: "exact", title: "Git Rebase", other: "git rebase", policy: value.MatchExact, expect: false},
+		{name: "case", title: "Git Rebase", other: "git rebase", policy: value.MatchCaseFold, expect: true},
+		{name: "NFD", title: "\u304c", other: "\u304b\u3099", policy: value.MatchExact, expect: false},
+		{name: "unicode", title: "\u304c", other: "\u304b\u3099", policy: value.MatchUnicode, expect: true},
+		{name: "spaces", title: "git rebase", other: "git   rebase", policy: value.MatchExact, expect: false},
+		{name: "space", title: "git rebase", other: "git   rebase", policy: value.MatchSpace, expect: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			title, err := value.NewTitle(tc.title)
+			require.NoError(t, err)
+			other, err := value.NewTitle(tc.other)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expect, title.Equals(other.WithMatchPolicy(tc.policy)))
+			assert.Equal(t, tc.expect, other.WithMatchPolicy(tc.policy).Equals(title))
+		})
+	}
+}
+
+func TestTitleSplitLocator(t *testing.T) {
+	t.Parallel()
+
+	t.Run("success cases", func(t *testing.T) {
+		t.Parallel()
+
+		tests := []struct {
+			name    string
+			title   string
+			locator string
+		}{
+			{name: "file", title: "title string [notes.md]", locator: "notes.md"},
+			{name: "file and line", title: "title string [notes.md:12]", locator: "notes.md:12"},
+			{name: "brackets in title", title: "title string [x] [notes.md]", locator: "notes.md"},
+		}
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				title, err := value.NewTitle(tc.title)
+				require.NoError(t, err)
+
+				base, locator, ok := title.SplitLocator()
+
+				require.True(t, ok)
+				assert.Equal(t, tc.locator, locator)
+				expected, err := value.NewTitle(strings.TrimSuffix(tc.title, " ["+tc.locator+"]"))
+				require.NoError(t, err)
+				assert.Equal(t, expected.String(), base.String())
+			})
+		}
+	})
+
+	t.Run("fail cases", func(t *testing.T) {
+		t.Parallel()
+
+		tests := []struct {
+			name  string
+			title string
+		}{
+			{name: "no locator", title: "title string"},
+			{name: "no space", title: "title[notes.md]"},
+			{name: "empty locator", title: "title string []"},
+			{name: "only locator", title: "[notes.md]"},
+			{name: "trailing string", title: "title string [notes.md] x"},
+		}
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				title, err := value.NewTitle(tc.title)
+				require.NoError(t, err)
+
+				_, _, ok := title.SplitLocator()
+
+				assert.False(t, ok)
+			})
+		}
+	})
+}
+
+func TestJoinLocator(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "title string [notes.md:12]", value.JoinLocator("title string", "notes.md:12"))
+}
@@ -1,12 +1,27 @@
 package value
 
-import "strings"
+import (
+	"regexp"
+	"strings"
+)
 
-const atxHeadingChar = "#"
+const (
+	atxHeadingChar = "#"
+
+	// Setext heading underline chars for level 1 and level 2 headings.
+	setextHeadingChars = "=-"
+
+	// Block structures allow up to three spaces of indentation.
+	maxIndent = 3
+)
+
+// headingIDPattern matches the heading ID attribute like "{#id}" at the end of the title.
+var headingIDPattern = regexp.MustCompile(`\s*\{#([^\s{}]+)\}$`)
 
 // TitleLine represents a title text line that allows empty titles.
 type TitleLine struct {
 	title *Title
+	id    string
 }
 
 // Title returns a title in the title line.
@@ -14,6 +29,11 @@ func (tl TitleLine) Title() Title {
 	return *tl.title
 }
 
+// ID returns the heading ID attribute in the title line, or an empty string if not found.
+func (tl TitleLine) ID() string {
+	return tl.id
+}
+
 // HasValidTitle reports whether a title in the title line is valid.
 func (tl TitleLine) HasValidTitle() bool {
 	return tl.title != nil
@@ -30,13 +50,38 @@ func NewTitleLine(tl string) (*TitleLine, bool) {
 		return nil, false
 	}
 
-	titleStr := strings.Trim(tl, atxHeadingChar+" ")
+	titleStr, id := splitHeadingID(strings.Trim(tl, atxHeadingChar+" "))
 	title, err := NewTitle(titleStr)
 	if err != nil {
 		return &TitleLine{title: nil}, true
 	}
 
-	return &TitleLine{title: title}, true
+	return &TitleLine{title: title, id: id}, true
+}
+
+// NewSetextTitleLine returns title line of the setext heading consisting of the text line and the underline.
+func NewSetextTitleLine(text, underline string) (*TitleLine, bool) {
+	if !isParagraphLine(text) || !isSetextUnderline(underline) {
+		return nil, false
+	}
+
+	titleStr, id := splitHeadingID(strings.TrimRight(text, " "))
+	title, err := NewTitle(titleStr)
+	if err != nil {
+		return nil, false
+	}
+
+	return &TitleLine{title: title, id: id}, true
+}
+
+// splitHeadingID splits the heading text into the title and the ID attribute like "title {#id}".
+func splitHeadingID(text string) (string, string) {
+	m := headingIDPattern.FindStringSubmatchIndex(text)
+	if m == nil {
+		return text, ""
+	}
+
+	return text[:m[0]], text[m[2]:m[3]]
 }
 
 // isTitleLine returns if the line is title line.
@@ -54,3 +99,38 @@ func isTitleLine(line string) bool {
 	// The title line must have a space after #.
 	return strings.HasPrefix(strings.TrimLeft(line, atxHeadingChar), " ")
 }
+
+// isParagraphLine reports whether the line can be the text of a setext heading.
+func isParagraphLine(line string) bool {
+	trimmedLine := strings.TrimLeft(line, " ")
+	if trimmedLine == "" || len(line)-len(trimmedLine) > maxIndent {
+		return false
+	}
+
+	if isTitleLine(line) || IsFenceLine(line) {
+		return false
+	}
+
+	// Block quotes and list items are not paragraphs.
+	for _, marker := range []string{">", "- ", "* ", "+ "} {
+		if strings.HasPrefix(trimmedLine, marker) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isSetextUnderline reports whether the line is an underline of a setext heading.
+func isSetextUnderline(line string) bool {
+	trimmedLine := strings.Trim(line, " ")
+	if trimmedLine == "" || len(line)-len(strings.TrimLeft(line, " ")) > maxIndent {
+		return false
+	}
+
+	if !strings.ContainsAny(trimmedLine[:1], setextHeadingChars) {
+		return false
+	}
+
+	return strings.Trim(trimmedLine, trimmedLine[:1]) == ""
+}
@@ -21,6 +21,7 @@ func TestNewTitleLine(t *testing.T) {
 		}{
 			{name: "normal title", titleLine: "# title string", validTitle: true},
 			{name: "blank title", titleLine: "#   ", validTitle: false},
+			{name: "only ID attribute", titleLine: "# {#id}", validTitle: false},
 		}
 
 		for _, tc := range tests {
@@ -87,3 +88,110 @@ func TestTitleLineEqualTitle(t *testing.T) {
 	assert.True(t, titleLine.EqualTitle(title))
 	assert.False(t, titleLine.EqualTitle(otherTitle))
 }
+
+func TestTitleLineID(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		titleLine string
+		title     string
+		id        string
+	}{
+		{name: "no ID", titleLine: "# title string", title: "title string", id: ""},
+		{name: "ID", titleLine: "# title string {#title-id}", title: "title string", id: "title-id"},
+		{name: "ID without space", titleLine: "# title string{#title-id}", title: "title string", id: "title-id"},
+		{name: "ID with trailing spaces", titleLine: "# title string {#title-id}  ", title: "title string", id: "title-id"},
+		{name: "ID in the middle", titleLine: "# title {#title-id} string", title: "title {#title-id} string", id: ""},
+		{name: "empty ID", titleLine: "# title string {#}", title: "title string {#}", id: ""},
+		{name: "ID with spaces", titleLine: "# title string {#title id}", title: "title string {#title id}", id: ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			titleLine, ok := value.NewTitleLine(tc.titleLine)
+
+			require.True(t, ok)
+			assert.Equal(t, tc.title, titleLine.Title().String())
+			assert.Equal(t, tc.id, titleLine.ID())
+		})
+	}
+
+	t.Run("setext heading", func(t *testing.T) {
+		t.Parallel()
+
+		titleLine, ok := value.NewSetextTitleLine("title string {#title-id}", "===")
+
+		require.True(t, ok)
+		assert.Equal(t, "title string", titleLine.Title().String())
+		assert.Equal(t, "title-id", titleLine.ID())
+	})
+}
+
+func TestNewSetextTitleLine(t *testing.T) {
+	t.Parallel()
+
+	t.Run("returns True", func(t *testing.T) {
+		t.Parallel()
+
+		tests := []struct {
+			name      string
+			text      string
+			underline string
+		}{
+			{name: "level 1", text: "title string", underline: "==="},
+			{name: "level 2", text: "title string", underline: "---"},
+			{name: "single char underline", text: "title string", underline: "="},
+			{name: "spaces around underline", text: "title string", underline: "   ===  "},
+			{name: "spaces around text", text: "   title string  ", underline: "==="},
+		}
+
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				titleLine, ok := value.NewSetextTitleLine(tc.text, tc.underline)
+
+				require.True(t, ok)
+				require.True(t, titleLine.HasValidTitle())
+				title := titleLine.Title()
+				assert.Equal(t, "title string", title.String())
+			})
+		}
+	})
+
+	t.Run("returns False", func(t *testing.T) {
+		t.Parallel()
+
+		tests := []struct {
+			name      string
+			text      string
+			underline string
+		}{
+			{name: "blank text", text: "  ", underline: "==="},
+			{name: "indented text", text: "    title string", underline: "==="},
+			{name: "ATX title", text: "# title string", underline: "==="},
+			{name: "fence", text: "```", underline: "==="},
+			{name: "block quote", text: "> quote", underline: "==="},
+			{name: "list item", text: "- item", underline: "---"},
+			{name: "no underline", text: "title string", underline: "text"},
+			{name: "empty underline", text: "title string", underline: ""},
+			{name: "mixed underline", text: "title string", underline: "=-="},
+			{name: "spaces in underline", text: "title string", underline: "= ="},
+			{name: "indented underline", text: "title string", underline: "    ==="},
+		}
+
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				titleLine, ok := value.NewSetextTitleLine(tc.text, tc.underline)
+
+				require.False(t, ok)
+				assert.Nil(t, titleLine)
+			})
+		}
+	})
+}
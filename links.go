@@ -0,0 +1,111 @@
+package fcqs
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+
+	"github.com/yendo/fcqs/internal/value"
+)
+
+// linkPattern matches the wiki-style link like "[[title]]".
+var linkPattern = regexp.MustCompile(`\[\[([^\[\]]+)\]\]`)
+
+// link represents a wiki-style link in the contents of a note.
+type link struct {
+	title *value.Title
+	line  line
+}
+
+// linkedNote represents a note with the links in its contents.
+type linkedNote struct {
+	*note
+	links []link
+}
+
+// linksTo reports whether the note has a link to the other note.
+func (n *linkedNote) linksTo(other *linkedNote) bool {
+	return slices.ContainsFunc(n.links, func(l link) bool { return other.hasTitle(l.title) })
+}
+
+// WriteLinks writes the titles of the notes linked from the note.
+// A warning is written for a link to the note not found.
+func WriteLinks(w io.Writer, r io.Reader, ref value.NoteRef) error {
+	notes, err := seekLinks(r, matchPolicyOf(ref))
+	if err != nil {
+		return err
+	}
+
+	var written []string
+	for _, n := range notes {
+		if !n.refersTo(ref) {
+			continue
+		}
+
+		for _, l := range n.links {
+			i := slices.IndexFunc(notes, func(target *linkedNote) bool { return target.hasTitle(l.title) })
+			if i < 0 {
+				fmt.Fprintf(warnWriter, "warning: %s: broken link: %s\n", l.line.location(), l.title)
+				continue
+			}
+			if t := notes[i].displayTitle(); !slices.Contains(written, t) {
+				fmt.Fprintln(w, t)
+				written = append(written, t)
+			}
+		}
+	}
+
+	return nil
+}
+
+// WriteBacklinks writes the titles of the notes that link to the note.
+func WriteBacklinks(w io.Writer, r io.Reader, ref value.NoteRef) error {
+	notes, err := seekLinks(r, matchPolicyOf(ref))
+	if err != nil {
+		return err
+	}
+
+	targets := slices.DeleteFunc(slices.Clone(notes), func(n *linkedNote) bool { return !n.refersTo(ref) })
+
+	var written []string
+	for _, n := range notes {
+		if !slices.ContainsFunc(targets, n.linksTo) {
+			continue
+		}
+		if t := n.displayTitle(); !slices.Contains(written, t) {
+			fmt.Fprintln(w, t)
+			written = append(written, t)
+		}
+	}
+
+	return nil
+}
+
+// seekLinks returns all notes with the links in their contents.
+// Links in fenced code blocks are ignored.
+func seekLinks(r io.Reader, policy value.MatchPolicy) ([]*linkedNote, error) {
+	notes, err := seekContents(r)
+	if err != nil {
+		return nil, err
+	}
+
+	linked := make([]*linkedNote, 0, len(notes))
+	for _, n := range notes {
+		ln := &linkedNote{note: n.note}
+
+		for _, line := range n.lines {
+			if line.kind != textLine {
+				continue
+			}
+			for _, m := range linkPattern.FindAllStringSubmatch(line.text, -1) {
+				if t, err := value.NewTitle(m[1]); err == nil {
+					ln.links = append(ln.links, link{title: t.WithMatchPolicy(policy), line: line})
+				}
+			}
+		}
+		linked = append(linked, ln)
+	}
+
+	return linked, nil
+}
@@ -0,0 +1,128 @@
+package fcqs
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	metadataStart = "<!--"
+	metadataEnd   = "-->"
+
+	listSeparator = ","
+)
+
+// errUnknownKey is the error of a line without a known key in the metadata.
+var errUnknownKey = errors.New("unknown key")
+
+// metadata represents the metadata of a note written in an HTML comment below the title.
+type metadata struct {
+	aliases []string
+	tags    []string
+	created string
+	updated string
+	source  string
+	pinned  bool
+
+	// workdir and env are the working directory and the environment variables like "KEY=value"
+	// to run the command-line block of the note.
+	workdir string
+	env     []string
+}
+
+// invalidMetadata represents an invalid line in the metadata.
+type invalidMetadata struct {
+	// index is the index of the line in the HTML comment.
+	index int
+	err   error
+}
+
+// newMetadata returns the metadata parsed from the lines of an HTML comment.
+// The comment is metadata if it has known keys, or nil is returned.
+// The lines with unknown keys or invalid values in the metadata are ignored and returned as invalid ones.
+func newMetadata(lines []string) (*metadata, []invalidMetadata) {
+	text := strings.TrimSpace(strings.Join(lines, "\n"))
+	if !strings.HasPrefix(text, metadataStart) || !strings.HasSuffix(text, metadataEnd) {
+		return nil, nil
+	}
+	text = strings.TrimSuffix(strings.TrimPrefix(text, metadataStart), metadataEnd)
+
+	var md metadata
+	var invalid []invalidMetadata
+	hasKey := false
+
+	for i, l := range strings.Split(text, "\n") {
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+
+		err := md.set(l)
+		if err != nil {
+			invalid = append(invalid, invalidMetadata{index: i, err: err})
+		}
+		if !errors.Is(err, errUnknownKey) {
+			hasKey = true
+		}
+	}
+
+	if !hasKey {
+		return nil, nil
+	}
+
+	return &md, invalid
+}
+
+// set sets the value of the "key: value" line to the metadata.
+func (md *metadata) set(l string) error {
+	key, val, ok := strings.Cut(l, ":")
+	if !ok {
+		return fmt.Errorf("%w: %s", errUnknownKey, strings.TrimSpace(l))
+	}
+	key, val = strings.TrimSpace(key), strings.TrimSpace(val)
+
+	switch key {
+	case "aliases":
+		md.aliases = splitList(val)
+	case "tags":
+		md.tags = splitList(val)
+	case "created":
+		md.created = val
+	case "updated":
+		md.updated = val
+	case "source":
+		md.source = val
+	case "pinned":
+		pinned, err := strconv.ParseBool(val)
+		if err != nil {
+			return fmt.Errorf("pinned: not a boolean: %s", val)
+		}
+		md.pinned = pinned
+	case "workdir":
+		md.workdir = val
+	case "env":
+		env := splitList(val)
+		if i := slices.IndexFunc(env, func(e string) bool { return !strings.Contains(e, "=") }); i >= 0 {
+			return fmt.Errorf("env: no value: %s", env[i])
+		}
+		md.env = env
+	default:
+		return fmt.Errorf("%w: %s", errUnknownKey, key)
+	}
+
+	return nil
+}
+
+// splitList returns non-empty items of the comma separated list.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, listSeparator) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
@@ -0,0 +1,267 @@
+package fcqs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/yendo/fcqs/internal/value"
+)
+
+// idLength is the length of the ID generated from the file and the title.
+const idLength = 12
+
+// note represents a note headed by a title line.
+type note struct {
+	title       value.Title
+	headingID   string
+	fileName    string
+	num         int
+	occurrence  int
+	frontMatter *frontMatter
+	metadata    *metadata
+}
+
+// fileFrontMatter returns the front matter of the file with the note.
+// It is nil if the note is nil or the file has no front matter.
+func (n *note) fileFrontMatter() *frontMatter {
+	if n == nil {
+		return nil
+	}
+
+	return n.frontMatter
+}
+
+// displayTitle returns the title shown in the title list.
+func (n *note) displayTitle() string {
+	return n.frontMatter.displayTitle(n.title.String())
+}
+
+// id returns the stable ID of the note.
+// It is the heading ID attribute like "{#id}" if the title has it,
+// or the hash of the base name of the file and the title otherwise.
+// The occurrence of the same base name and title is added to the hash after the first one.
+func (n *note) id() string {
+	if n.headingID != "" {
+		return n.headingID
+	}
+
+	key := idKey(n.fileName, n.title.String())
+	if n.occurrence > 0 {
+		key += "\x00" + strconv.Itoa(n.occurrence)
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])[:idLength]
+}
+
+// idKey returns the key of the generated ID with the base name of the file and the title.
+func idKey(fileName, title string) string {
+	if fileName != "" {
+		fileName = filepath.Base(fileName)
+	}
+
+	return fileName + "\x00" + title
+}
+
+// aliases returns the alternative titles of the note.
+func (n *note) aliases() []string {
+	if n.metadata == nil {
+		return nil
+	}
+
+	return n.metadata.aliases
+}
+
+// refersTo reports whether the reference is the title, the alias or the ID of the note.
+func (n *note) refersTo(ref value.NoteRef) bool {
+	switch ref := ref.(type) {
+	case *value.Title:
+		return n.hasTitle(ref)
+	case *value.ID:
+		return ref.String() == n.id()
+	default:
+		return false
+	}
+}
+
+// hasTitle reports whether the note has the title or the alias.
+// The title may have the display prefix of the notes file,
+// and the locator of the note like "title [file:line]".
+func (n *note) hasTitle(title *value.Title) bool {
+	if n.hasName(title) {
+		return true
+	}
+
+	t, locator, ok := title.SplitLocator()
+	return ok && n.hasName(t) && n.hasLocator(locator)
+}
+
+// isAt reports whether the note is at the same location as the other note.
+func (n *note) isAt(other *note) bool {
+	return n.fileName == other.fileName && n.num == other.num
+}
+
+// isUniqueRef reports whether the reference refers to only one note,
+// which is the ID or the title with the locator of the line like "title [file:line]".
+func isUniqueRef(ref value.NoteRef) bool {
+	switch ref := ref.(type) {
+	case *value.ID:
+		return true
+	case *value.Title:
+		_, locator, ok := ref.SplitLocator()
+		return ok && strings.Contains(locator, ":")
+	default:
+		return false
+	}
+}
+
+// matchPolicyOf returns the match policy of the reference to find the titles in the note.
+// The titles are matched exactly for the ID.
+func matchPolicyOf(ref value.NoteRef) value.MatchPolicy {
+	if title, ok := ref.(*value.Title); ok {
+		return title.MatchPolicy()
+	}
+
+	return value.MatchExact
+}
+
+// hasName reports whether the title is the title or the alias of the note.
+func (n *note) hasName(title *value.Title) bool {
+	names := append([]string{n.title.String()}, n.aliases()...)
+
+	for _, name := range names {
+		for _, s := range []string{name, n.frontMatter.displayTitle(name)} {
+			if t, err := value.NewTitle(s); err == nil && t.Equals(title) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// locator returns the base name or the path of the file with the note, and the line number if needed.
+func (n *note) locator(withPath, withLine bool) string {
+	var locator string
+	switch {
+	case withPath:
+		locator = n.fileName
+	case n.fileName != "":
+		locator = filepath.Base(n.fileName)
+	}
+	if withLine || locator == "" {
+		locator += fmt.Sprintf(":%d", n.num)
+	}
+
+	return locator
+}
+
+// hasLocator reports whether the locator like "file" or "file:line" points to the note.
+func (n *note) hasLocator(locator string) bool {
+	if i := strings.LastIndex(locator, ":"); i >= 0 {
+		if num, err := strconv.Atoi(locator[i+1:]); err == nil {
+			if num != n.num {
+				return false
+			}
+			locator = locator[:i]
+		}
+	}
+
+	return locator == n.fileName || n.fileName != "" && locator == filepath.Base(n.fileName)
+}
+
+// isPinned reports whether the note is pinned.
+func (n *note) isPinned() bool {
+	return n.metadata != nil && n.metadata.pinned
+}
+
+// info returns the information of the note.
+func (n *note) info() *noteInfo {
+	info := &noteInfo{
+		ID:    n.id(),
+		Title: n.title.String(),
+		File:  n.fileName,
+		Line:  n.num,
+	}
+
+	if fm := n.frontMatter; fm != nil {
+		info.Prefix = fm.Prefix
+		info.Notebook = fm.Notebook
+		info.Shell = fm.Shell
+		info.Tags = slices.Clone(fm.Tags)
+	}
+
+	if md := n.metadata; md != nil {
+		for _, tag := range md.tags {
+			if !slices.Contains(info.Tags, tag) {
+				info.Tags = append(info.Tags, tag)
+			}
+		}
+		info.Aliases = md.aliases
+		info.Created = md.created
+		info.Updated = md.updated
+		info.Source = md.source
+		info.Pinned = md.pinned
+		info.Workdir = md.workdir
+		info.Env = md.env
+	}
+
+	return info
+}
+
+// noteInfo represents the information of a note for output.
+type noteInfo struct {
+	ID       string   `json:"id"`
+	Title    string   `json:"title"`
+	Prefix   string   `json:"prefix,omitempty"`
+	File     string   `json:"file"`
+	Line     int      `json:"line"`
+	Notebook string   `json:"notebook,omitempty"`
+	Shell    string   `json:"shell,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Aliases  []string `json:"aliases,omitempty"`
+	Created  string   `json:"created,omitempty"`
+	Updated  string   `json:"updated,omitempty"`
+	Source   string   `json:"source,omitempty"`
+	Pinned   bool     `json:"pinned,omitempty"`
+	Workdir  string   `json:"workdir,omitempty"`
+	Env      []string `json:"env,omitempty"`
+}
+
+// writeText writes the information as "key: value" lines.
+func (info *noteInfo) writeText(w io.Writer) {
+	fields := []struct {
+		key string
+		val string
+	}{
+		{"id", info.ID},
+		{"title", info.Title},
+		{"prefix", info.Prefix},
+		{"file", info.File},
+		{"line", fmt.Sprint(info.Line)},
+		{"notebook", info.Notebook},
+		{"shell", info.Shell},
+		{"tags", strings.Join(info.Tags, listSeparator+" ")},
+		{"aliases", strings.Join(info.Aliases, listSeparator+" ")},
+		{"created", info.Created},
+		{"updated", info.Updated},
+		{"source", info.Source},
+		{"workdir", info.Workdir},
+		{"env", strings.Join(info.Env, listSeparator+" ")},
+	}
+
+	for _, f := range fields {
+		if f.val != "" {
+			fmt.Fprintf(w, "%s: %s\n", f.key, f.val)
+		}
+	}
+	if info.Pinned {
+		fmt.Fprintln(w, "pinned: true")
+	}
+}
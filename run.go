@@ -0,0 +1,144 @@
+package fcqs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/yendo/fcqs/internal/value"
+)
+
+// defaultShell is the shell to run command lines if SHELL is not set.
+const defaultShell = "/bin/sh"
+
+// CmdLine represents the first command-line block of a note to run.
+type CmdLine struct {
+	// Code is the command lines in the block.
+	Code string
+
+	// ID and Title are the ID and the title of the note.
+	ID    string
+	Title string
+
+	// Dir is the working directory declared in the metadata of the note.
+	// The command lines run in the current directory if it is empty.
+	Dir string
+
+	// Env is the environment variables like "KEY=value" declared in the metadata of the note.
+	Env []string
+}
+
+// SeekCmdLine returns the first command-line block in the contents of the note
+// with the working directory and the environment variables of the note, or nil if not found.
+func SeekCmdLine(r io.Reader, ref value.NoteRef) (*CmdLine, error) {
+	lines, n, err := seekFirstCmdLineBlock(r, ref)
+	if err != nil || len(lines) == 0 {
+		return nil, err
+	}
+
+	cl := &CmdLine{
+		Code:  strings.Join(lines, "\n") + "\n",
+		ID:    n.id(),
+		Title: n.title.String(),
+	}
+
+	if md := n.metadata; md != nil {
+		for _, env := range md.env {
+			env, err := expandEnvHome(env)
+			if err != nil {
+				return nil, fmt.Errorf("env: %w", err)
+			}
+			cl.Env = append(cl.Env, env)
+		}
+		if md.workdir != "" {
+			dir, err := n.resolvePath(md.workdir)
+			if err != nil {
+				return nil, fmt.Errorf("working directory: %w", err)
+			}
+			cl.Dir = dir
+		}
+	}
+
+	return cl, nil
+}
+
+// Run runs the command lines in the shell of SHELL and returns the exit status.
+func (cl *CmdLine) Run(stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	cmd := exec.Command(shell(), "-c", cl.Code)
+	cmd.Dir = cl.Dir
+	cmd.Env = append(os.Environ(), cl.Env...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = stdin, stdout, stderr
+
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode(), nil
+		}
+		return 0, fmt.Errorf("run: %w", err)
+	}
+
+	return 0, nil
+}
+
+// shell returns the shell of SHELL to run command lines.
+func shell() string {
+	if sh := os.Getenv("SHELL"); sh != "" {
+		return sh
+	}
+
+	return defaultShell
+}
+
+// resolvePath returns the path with "~" expanded to the home directory.
+// A relative path is relative to the directory of the file with the note.
+func (n *note) resolvePath(path string) (string, error) {
+	if isHomePath(path) {
+		return expandHome(path)
+	}
+
+	if filepath.IsAbs(path) || n.fileName == "" {
+		return path, nil
+	}
+
+	return filepath.Join(filepath.Dir(n.fileName), path), nil
+}
+
+// expandEnvHome returns the environment variable like "KEY=~/path" with "~" expanded to the home directory
+// at the start of the value and after colons, as shells do in assignments like "PATH=~/bin:~/.local/bin".
+func expandEnvHome(env string) (string, error) {
+	key, val, _ := strings.Cut(env, "=")
+
+	paths := strings.Split(val, string(os.PathListSeparator))
+	for i, path := range paths {
+		if !isHomePath(path) {
+			continue
+		}
+
+		expanded, err := expandHome(path)
+		if err != nil {
+			return "", err
+		}
+		paths[i] = expanded
+	}
+
+	return key + "=" + strings.Join(paths, string(os.PathListSeparator)), nil
+}
+
+// isHomePath reports whether the path starts with "~" for the home directory.
+func isHomePath(path string) bool {
+	return path == "~" || strings.HasPrefix(path, "~/")
+}
+
+// expandHome returns the path starting with "~" expanded to the home directory.
+func expandHome(path string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, path[1:]), nil
+}
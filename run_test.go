@@ -0,0 +1,116 @@
+package fcqs_test
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yendo/fcqs"
+	"github.com/yendo/fcqs/internal/value"
+	"github.com/yendo/fcqs/test"
+)
+
+func TestSeekCmdLine(t *testing.T) {
+	t.Setenv("HOME", "/home/fcqs")
+
+	tests := []struct {
+		title    string
+		expected *fcqs.CmdLine
+	}{
+		{
+			"greet",
+			&fcqs.CmdLine{
+				Code:  "echo \"$GREETING $NAME in $(basename \"$(pwd)\")\"\n",
+				Title: "greet",
+				Dir:   filepath.Join(filepath.Dir(test.RunFile), "../testdata"),
+				Env:   []string{"GREETING=hello", "NAME=fcqs"},
+			},
+		},
+		{"fail", &fcqs.CmdLine{Code: "exit 3\n", Title: "fail"}},
+		{
+			"home",
+			&fcqs.CmdLine{
+				Code:  "pwd\n",
+				Title: "home",
+				Dir:   "/home/fcqs",
+				Env:   []string{"KUBECONFIG=/home/fcqs/.kube/dev", "PATH=/home/fcqs/bin:/usr/bin", "TILDE=a~b"},
+			},
+		},
+		{"no command", nil},
+		{"unknown", nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.title, func(t *testing.T) {
+			file := openTestNotesFile(t, test.RunFile)
+			title, err := value.NewTitle(tc.title)
+			require.NoError(t, err)
+
+			cl, err := fcqs.SeekCmdLine(file, title)
+
+			require.NoError(t, err)
+			// The ID is generated from the file name and the title.
+			if tc.expected != nil {
+				require.NotNil(t, cl)
+				assert.Len(t, cl.ID, 12)
+				tc.expected.ID = cl.ID
+			}
+			assert.Equal(t, tc.expected, cl)
+		})
+	}
+
+	t.Run("scan error", func(t *testing.T) {
+		r := iotest.ErrReader(ErrScanForTest)
+		title, err := value.NewTitle("greet")
+		require.NoError(t, err)
+
+		cl, err := fcqs.SeekCmdLine(r, title)
+
+		require.EqualError(t, err, fmt.Sprintf("seek contents: %s", ErrScanForTest))
+		assert.Nil(t, cl)
+	})
+}
+
+func TestCmdLineRun(t *testing.T) {
+	t.Setenv("SHELL", "/bin/sh")
+
+	t.Run("succeeded", func(t *testing.T) {
+		dir := t.TempDir()
+		cl := &fcqs.CmdLine{Code: "read answer\necho \"$answer $NAME in $(pwd)\"\n", Dir: dir, Env: []string{"NAME=fcqs"}}
+
+		var stdout, stderr bytes.Buffer
+		status, err := cl.Run(strings.NewReader("hello\n"), &stdout, &stderr)
+
+		require.NoError(t, err)
+		assert.Equal(t, 0, status)
+		assert.Equal(t, fmt.Sprintf("hello fcqs in %s\n", dir), stdout.String())
+		assert.Empty(t, stderr.String())
+	})
+
+	t.Run("exit status", func(t *testing.T) {
+		cl := &fcqs.CmdLine{Code: "echo failed >&2\nexit 3\n"}
+
+		var stdout, stderr bytes.Buffer
+		status, err := cl.Run(strings.NewReader(""), &stdout, &stderr)
+
+		require.NoError(t, err)
+		assert.Equal(t, 3, status)
+		assert.Empty(t, stdout.String())
+		assert.Equal(t, "failed\n", stderr.String())
+	})
+
+	t.Run("working directory not found", func(t *testing.T) {
+		cl := &fcqs.CmdLine{Code: "pwd\n", Dir: filepath.Join(t.TempDir(), "missing")}
+
+		var stdout, stderr bytes.Buffer
+		status, err := cl.Run(strings.NewReader(""), &stdout, &stderr)
+
+		require.ErrorContains(t, err, "run: chdir")
+		assert.Equal(t, 0, status)
+	})
+}
@@ -0,0 +1,391 @@
+package fcqs
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/yendo/fcqs/internal/value"
+)
+
+// Kinds of lines in notes files.
+const (
+	textLine = iota
+	titleLine
+	underline
+	metadataLine
+	openingFenceLine
+	closingFenceLine
+	codeLine
+	includeLine
+)
+
+// includePattern matches the include directive like "<!-- include: ./notes.md -->".
+var includePattern = regexp.MustCompile(`^<!--\s*include:\s*(\S.*?)\s*-->$`)
+
+// warnWriter is the writer for warnings about notes files.
+var warnWriter io.Writer = os.Stderr
+
+// line represents a text line in notes files.
+type line struct {
+	text     string
+	kind     int
+	note     *note
+	fileName string
+	num      int
+}
+
+// location returns the file name and the line number of the line.
+func (l line) location() string {
+	if l.fileName == "" {
+		return fmt.Sprintf("line %d", l.num)
+	}
+	return fmt.Sprintf("%s:%d", l.fileName, l.num)
+}
+
+// refersTo reports whether the line is a title line of the note referred to by the reference.
+func (l line) refersTo(ref value.NoteRef) bool {
+	return l.kind == titleLine && l.note != nil && l.note.refersTo(ref)
+}
+
+// source represents a notes file to be scanned.
+type source struct {
+	name    string
+	scanner *bufio.Scanner
+
+	// includers are the names of the files including the file.
+	includers []string
+
+	// state is the scanning state saved while an included file is scanned.
+	state fileState
+}
+
+// fileState represents the scanning state of a notes file.
+type fileState struct {
+	line        line
+	current     *note
+	pending     []string
+	frontMatter *frontMatter
+	isUnderline bool
+	metadataEnd int
+	fence       *value.FenceLine
+	fenceLine   line
+}
+
+// notesScanner scans notes files line by line keeping track of fenced code blocks.
+// A notes file can include other files with the include directive.
+type notesScanner struct {
+	sources []source
+	fileState
+	err error
+
+	// quiet suppresses the warnings for the notes files scanned again.
+	quiet bool
+
+	// occurrences counts the notes without heading IDs by the base name of the file and the title.
+	occurrences map[string]int
+
+	// frontMatterLines are the lines of the front matters, which are not scanned as lines.
+	frontMatterLines []line
+}
+
+// Scan advances the scanner to the next line.
+func (s *notesScanner) Scan() bool {
+	if s.line.kind == includeLine {
+		s.include()
+	}
+
+	for len(s.sources) > 0 {
+		src := s.sources[0]
+		if s.line.num == 0 {
+			s.readFrontMatter(src.name)
+		}
+		if text, ok := s.read(); ok {
+			s.next(src.name, text)
+			return true
+		}
+		if err := src.scanner.Err(); err != nil {
+			s.err = err
+			return false
+		}
+
+		// An unterminated fenced code block is closed at the end of the file.
+		if s.fence != nil {
+			s.warnf("warning: %s: unterminated fenced code block\n", s.fenceLine.location())
+			s.fence = nil
+		}
+		s.sources = s.sources[1:]
+		if len(s.sources) > 0 {
+			// The including file is resumed in the saved state.
+			s.fileState = s.sources[0].state
+		}
+	}
+
+	return false
+}
+
+// include starts to scan the file of the include directive in the current line.
+// The path of the file is relative to the including file.
+func (s *notesScanner) include() {
+	current := &s.sources[0]
+
+	name := includePattern.FindStringSubmatch(strings.TrimSpace(s.line.text))[1]
+	if !filepath.IsAbs(name) {
+		name = filepath.Join(filepath.Dir(current.name), name)
+	}
+
+	includers := append(slices.Clone(current.includers), current.name)
+	if slices.ContainsFunc(includers, func(n string) bool { return canonicalPath(n) == canonicalPath(name) }) {
+		s.warnf("warning: %s: include cycle: %s\n", s.line.location(), name)
+		return
+	}
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		s.warnf("warning: %s: include: %s\n", s.line.location(), err)
+		return
+	}
+
+	current.state = s.fileState
+	s.fileState = fileState{}
+	included := source{name: name, scanner: bufio.NewScanner(bytes.NewReader(data)), includers: includers}
+	s.sources = append([]source{included}, s.sources...)
+}
+
+// canonicalPath returns the absolute path of the file without symbolic links
+// to compare the paths written in different ways. The path is returned as clean as possible on errors.
+func canonicalPath(name string) string {
+	if abs, err := filepath.Abs(name); err == nil {
+		name = abs
+	}
+	if real, err := filepath.EvalSymlinks(name); err == nil {
+		name = real
+	}
+
+	return filepath.Clean(name)
+}
+
+// read reads the next text line in the current file.
+func (s *notesScanner) read() (string, bool) {
+	if len(s.pending) > 0 {
+		text := s.pending[0]
+		s.pending = s.pending[1:]
+		return text, true
+	}
+
+	if !s.sources[0].scanner.Scan() {
+		return "", false
+	}
+	return s.sources[0].scanner.Text(), true
+}
+
+// peek returns the n-th next text line in the current file without advancing the scanner.
+func (s *notesScanner) peek(n int) (string, bool) {
+	for len(s.pending) <= n {
+		if !s.sources[0].scanner.Scan() {
+			return "", false
+		}
+		s.pending = append(s.pending, s.sources[0].scanner.Text())
+	}
+
+	return s.pending[n], true
+}
+
+// readFrontMatter reads the front matter at the beginning of the current file.
+func (s *notesScanner) readFrontMatter(name string) {
+	if text, ok := s.peek(0); !ok || text != frontMatterDelimiter {
+		return
+	}
+
+	for n := 1; ; n++ {
+		text, ok := s.peek(n)
+		if !ok {
+			// The first line is not a front matter delimiter without the closing one.
+			return
+		}
+		if text != frontMatterDelimiter && text != frontMatterEndDelimiter {
+			continue
+		}
+
+		fm, err := newFrontMatter(s.pending[1:n])
+		if err != nil {
+			s.warnf("warning: %s: invalid front matter: %s\n", line{fileName: name, num: 1}.location(), err)
+		}
+
+		for i, text := range s.pending[:n+1] {
+			s.frontMatterLines = append(s.frontMatterLines, line{text: text, fileName: name, num: i + 1})
+		}
+
+		s.frontMatter = fm
+		s.pending = s.pending[n+1:]
+		s.line = line{fileName: name, num: n + 1}
+		return
+	}
+}
+
+// next sets the next line and its kind.
+func (s *notesScanner) next(name, text string) {
+	prev := s.line
+	s.line = line{text: text, kind: textLine, fileName: name, num: prev.num + 1}
+
+	fl, isFenceLine := value.NewFenceLine(text)
+
+	switch {
+	case s.fence != nil:
+		if isFenceLine && fl.Closes(s.fence) {
+			s.line.kind = closingFenceLine
+			s.fence = nil
+		} else {
+			s.line.kind = codeLine
+		}
+
+	case isFenceLine:
+		s.line.kind = openingFenceLine
+		s.fence, s.fenceLine = fl, s.line
+
+	case s.isUnderline:
+		s.line.kind = underline
+		s.isUnderline = false
+
+	case s.line.num <= s.metadataEnd:
+		s.line.kind = metadataLine
+
+	case includePattern.MatchString(strings.TrimSpace(text)):
+		s.line.kind = includeLine
+
+	default:
+		if tl, ok := value.NewTitleLine(text); ok {
+			s.setTitle(tl, 0)
+			return
+		}
+
+		// A setext heading is a line of a single-line paragraph followed by an underline.
+		if prev.text != "" && prev.kind == textLine {
+			return
+		}
+		if next, ok := s.peek(0); ok {
+			if tl, ok := value.NewSetextTitleLine(text, next); ok {
+				s.setTitle(tl, 1)
+				s.isUnderline = true
+			}
+		}
+	}
+}
+
+// setTitle sets the current line as the title line.
+// n is the index of the pending line next to the title line.
+func (s *notesScanner) setTitle(tl *value.TitleLine, n int) {
+	s.line.kind = titleLine
+	s.current = nil
+	if !tl.HasValidTitle() {
+		return
+	}
+
+	s.line.note = &note{
+		title:       tl.Title(),
+		headingID:   tl.ID(),
+		fileName:    s.line.fileName,
+		num:         s.line.num,
+		frontMatter: s.frontMatter,
+		metadata:    s.readMetadata(n),
+	}
+	if s.line.note.headingID == "" {
+		key := idKey(s.line.fileName, s.line.note.title.String())
+		s.line.note.occurrence = s.occurrences[key]
+		s.occurrences[key]++
+	}
+	s.current = s.line.note
+}
+
+// readMetadata reads the metadata in an HTML comment below the title line.
+// n is the index of the pending line next to the title line.
+func (s *notesScanner) readMetadata(n int) *metadata {
+	text, ok := s.peek(n)
+	for ok && strings.TrimSpace(text) == "" {
+		n++
+		text, ok = s.peek(n)
+	}
+	if !ok || !strings.HasPrefix(strings.TrimSpace(text), metadataStart) {
+		return nil
+	}
+
+	start := n
+	for ok && !strings.HasSuffix(strings.TrimSpace(text), metadataEnd) {
+		n++
+		text, ok = s.peek(n)
+	}
+	if !ok {
+		return nil
+	}
+
+	md, invalid := newMetadata(s.pending[start : n+1])
+	if md == nil {
+		return nil
+	}
+	for _, im := range invalid {
+		l := line{fileName: s.line.fileName, num: s.line.num + start + im.index + 1}
+		s.warnf("warning: %s: invalid metadata: %s\n", l.location(), im.err)
+	}
+
+	s.metadataEnd = s.line.num + n + 1
+	return md
+}
+
+// warnf writes the warning about the notes files unless the scanner is quiet.
+func (s *notesScanner) warnf(format string, a ...any) {
+	if !s.quiet {
+		fmt.Fprintf(warnWriter, format, a...)
+	}
+}
+
+// Line returns the current line.
+func (s *notesScanner) Line() line {
+	return s.line
+}
+
+// Note returns the note that the current line belongs to, or nil if the line is not in any note.
+// The lines after an include directive belong to the note with the directive.
+func (s *notesScanner) Note() *note {
+	return s.current
+}
+
+// FrontMatterLines returns the lines of the front matters read since the last call.
+func (s *notesScanner) FrontMatterLines() []line {
+	lines := s.frontMatterLines
+	s.frontMatterLines = nil
+	return lines
+}
+
+// Err returns the first error that was encountered by the scanner.
+func (s *notesScanner) Err() error {
+	return s.err
+}
+
+// newNotesScanner returns a scanner for the sources.
+func newNotesScanner(sources ...source) *notesScanner {
+	return &notesScanner{sources: sources, occurrences: make(map[string]int)}
+}
+
+// sourcesOf returns the notes files in the reader.
+func sourcesOf(r io.Reader) []source {
+	if nr, ok := r.(*notesReader); ok {
+		sources := make([]source, 0, len(nr.files))
+		for _, f := range nr.files {
+			sources = append(sources, source{name: f.Name(), scanner: bufio.NewScanner(f)})
+		}
+		return sources
+	}
+
+	var name string
+	if f, ok := r.(interface{ Name() string }); ok {
+		name = f.Name()
+	}
+
+	return []source{{name: name, scanner: bufio.NewScanner(r)}}
+}
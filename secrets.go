@@ -0,0 +1,308 @@
+package fcqs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const (
+	// minSecretLength and minSecretEntropy are the thresholds of high-entropy strings.
+	// The entropy is the Shannon entropy in bits per character.
+	minSecretLength  = 24
+	minSecretEntropy = 4.0
+
+	highEntropyKind = "high-entropy string"
+)
+
+var (
+	// ErrSecretNotSet is the error of a secret reference to an environment variable not set.
+	ErrSecretNotSet = errors.New("not set")
+
+	// ErrUnterminatedSecretRef is the error of a secret reference without the closing brace.
+	ErrUnterminatedSecretRef = errors.New("unterminated secret reference")
+
+	// ErrInvalidSecretName is the error of a secret reference to an invalid name of an environment variable.
+	ErrInvalidSecretName = errors.New("invalid name")
+)
+
+// secretPatterns are the patterns of common secret formats.
+var secretPatterns = []struct {
+	kind    string
+	pattern *regexp.Regexp
+}{
+	{"AWS access key ID", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"AWS secret access key", regexp.MustCompile(`(?i)aws.{0,20}secret.{0,20}[=:]\s*["']?[A-Za-z0-9/+]{40}\b`)},
+	{"GitHub token", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{"private key", regexp.MustCompile(`-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY-----`)},
+}
+
+// secretRefStartPattern matches the start of the secret references like "${env:NAME}" and "${cmd:command}".
+var secretRefStartPattern = regexp.MustCompile(`\$\{(env|cmd):`)
+
+// envNamePattern matches the names of environment variables expanded in shells.
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// secretRef represents a secret reference in a text.
+type secretRef struct {
+	// start and end are the byte offsets of the reference in the text.
+	start, end int
+	kind, name string
+}
+
+// tokenPattern matches the candidates of high-entropy strings.
+var tokenPattern = regexp.MustCompile(`[A-Za-z0-9+/=_\-]{24,}`)
+
+// Secret represents a possible secret in a line of text.
+type Secret struct {
+	Kind string
+
+	// Line is the line number of the secret in the text.
+	Line int
+}
+
+// FindSecrets returns the possible secrets in the text, such as AWS keys, GitHub tokens,
+// private key headers and high-entropy strings.
+func FindSecrets(text string) []Secret {
+	var secrets []Secret
+
+	for i, line := range strings.Split(text, "\n") {
+		if kind, ok := secretKind(line); ok {
+			secrets = append(secrets, Secret{Kind: kind, Line: i + 1})
+		}
+	}
+
+	return secrets
+}
+
+// LintSecrets writes the locations like "file:line" of the possible secrets in the notes files,
+// and returns the number of them.
+func LintSecrets(w io.Writer, r io.Reader) (int, error) {
+	n := 0
+	lint := func(lines ...line) {
+		for _, l := range lines {
+			if kind, ok := secretKind(l.text); ok {
+				fmt.Fprintf(w, "%s: possible secret: %s\n", l.location(), kind)
+				n++
+			}
+		}
+	}
+
+	scanner := newNotesScanner(sourcesOf(r)...)
+	for scanner.Scan() {
+		// The front matter lines are before the line.
+		lint(scanner.FrontMatterLines()...)
+		lint(scanner.Line())
+	}
+	if err := scanner.Err(); err != nil {
+		return n, fmt.Errorf("lint secrets: %w", err)
+	}
+	lint(scanner.FrontMatterLines()...)
+
+	return n, nil
+}
+
+// secretKind returns the kind of the first possible secret in the line.
+func secretKind(line string) (string, bool) {
+	for _, p := range secretPatterns {
+		if p.pattern.MatchString(line) {
+			return p.kind, true
+		}
+	}
+
+	for _, token := range tokenPattern.FindAllString(line, -1) {
+		if isHighEntropy(token) {
+			return highEntropyKind, true
+		}
+	}
+
+	return "", false
+}
+
+// isHighEntropy reports whether the token looks random with upper and lower case letters and digits.
+func isHighEntropy(token string) bool {
+	if len(token) < minSecretLength {
+		return false
+	}
+
+	hasUpper := strings.IndexFunc(token, unicode.IsUpper) >= 0
+	hasLower := strings.IndexFunc(token, unicode.IsLower) >= 0
+	hasDigit := strings.IndexFunc(token, unicode.IsDigit) >= 0
+	if !hasUpper || !hasLower || !hasDigit {
+		return false
+	}
+
+	return entropy(token) >= minSecretEntropy
+}
+
+// entropy returns the Shannon entropy of the string in bits per character.
+func entropy(s string) float64 {
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+
+	e := 0.0
+	for _, c := range counts {
+		p := float64(c) / float64(len(s))
+		e -= p * math.Log2(p)
+	}
+
+	return e
+}
+
+// ResolveSecrets returns the text with the secret references replaced with their values.
+// "${env:NAME}" is the value of the environment variable,
+// and "${cmd:command}" is the output of the command run in the shell without the trailing newlines.
+// The braces in the command like "${cmd:awk '{print $1}' file}" must be balanced.
+func ResolveSecrets(text string) (string, error) {
+	return replaceSecretRefs(text, func(ref secretRef, _ string) (string, error) {
+		val, err := resolveSecret(ref.kind, ref.name)
+		if err != nil {
+			return "", fmt.Errorf("resolve secret: %s:%s: %w", ref.kind, ref.name, err)
+		}
+		return val, nil
+	})
+}
+
+// ExpandSecrets returns the shell code with the secret references replaced with the shell expansions of them,
+// like "${NAME}" for "${env:NAME}" and "$(command)" for "${cmd:command}", quoted in the context of the code.
+// The secrets are resolved by the shell only when the code runs, and are not written in the code.
+func ExpandSecrets(code string) (string, error) {
+	return replaceSecretRefs(code, func(ref secretRef, before string) (string, error) {
+		expansion := "$(" + ref.name + ")"
+		if ref.kind == "env" {
+			if !envNamePattern.MatchString(ref.name) {
+				return "", fmt.Errorf("expand secret: %s:%s: %w", ref.kind, ref.name, ErrInvalidSecretName)
+			}
+			expansion = "${" + ref.name + "}"
+		}
+
+		switch openQuote(before) {
+		case '"':
+			return expansion, nil
+		case '\'':
+			return `'"` + expansion + `"'`, nil
+		default:
+			return `"` + expansion + `"`, nil
+		}
+	})
+}
+
+// replaceSecretRefs returns the text with the secret references replaced by the function.
+// The function is given the text before the reference without the other references.
+func replaceSecretRefs(text string, replace func(ref secretRef, before string) (string, error)) (string, error) {
+	refs, err := findSecretRefs(text)
+	if err != nil {
+		return "", err
+	}
+
+	var sb, before strings.Builder
+	prev := 0
+	for _, ref := range refs {
+		before.WriteString(text[prev:ref.start])
+		val, err := replace(ref, before.String())
+		if err != nil {
+			return "", err
+		}
+
+		sb.WriteString(text[prev:ref.start])
+		sb.WriteString(val)
+		prev = ref.end
+	}
+	sb.WriteString(text[prev:])
+
+	return sb.String(), nil
+}
+
+// findSecretRefs returns the secret references in the text.
+// A reference ends at the brace balanced with the opening brace.
+func findSecretRefs(text string) ([]secretRef, error) {
+	var refs []secretRef
+
+	for offset := 0; ; {
+		m := secretRefStartPattern.FindStringSubmatchIndex(text[offset:])
+		if m == nil {
+			return refs, nil
+		}
+
+		start, nameStart := offset+m[0], offset+m[1]
+		n := closingBrace(text[nameStart:])
+		if n < 0 {
+			return nil, fmt.Errorf("%w: %s", ErrUnterminatedSecretRef, text[start:])
+		}
+
+		refs = append(refs, secretRef{
+			start: start,
+			end:   nameStart + n + 1,
+			kind:  text[offset+m[2] : offset+m[3]],
+			name:  strings.TrimSpace(text[nameStart : nameStart+n]),
+		})
+		offset = nameStart + n + 1
+	}
+}
+
+// closingBrace returns the index of the closing brace balanced with the opening brace before the text, or -1.
+func closingBrace(text string) int {
+	depth := 1
+	for i, r := range text {
+		switch r {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+// openQuote returns the quote left open at the end of the shell code, or 0 if no quote is open.
+func openQuote(code string) rune {
+	var quote rune
+	isEscaped := false
+
+	for _, r := range code {
+		switch {
+		case isEscaped:
+			isEscaped = false
+		case r == '\\' && quote != '\'':
+			isEscaped = true
+		case quote == 0 && (r == '\'' || r == '"'):
+			quote = r
+		case r == quote:
+			quote = 0
+		}
+	}
+
+	return quote
+}
+
+// resolveSecret returns the value of the secret reference of the kind.
+func resolveSecret(kind, ref string) (string, error) {
+	if kind == "env" {
+		val, ok := os.LookupEnv(ref)
+		if !ok {
+			return "", ErrSecretNotSet
+		}
+		return val, nil
+	}
+
+	cmd := exec.Command(shell(), "-c", ref)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(string(out), "\r\n"), nil
+}
@@ -4,21 +4,41 @@
 #
 # FCQS_EDITOR="default" or "vscode"
 # FCQS_COPY_KEY="ctrl-y"
+# FCQS_COPY_BLOCK_KEY="alt-y"
 # FCQS_OPEN_KEY="ctrl-o"
 # FCQS_EDIT_KEY="ctrl-e"
+# FCQS_LINKS_KEY="alt-l"
+# FCQS_BACKLINKS_KEY="alt-b"
 # FCQS_BASH_BIND_KEY="\C-o"
-# FCQS_COPY_COMMAND="xclip -selection c"
+# FCQS_COPY_COMMAND=""
 # FCQS_COPY_WITH_TITLE=true
 # FCQS_OPEN_COMMAND="open"
+# FCQS_URL_MATCH=""
+# FCQS_RELAXED_URLS=false
+# FCQS_RESOLVE_SECRETS=false
+# FCQS_JOIN_COMMANDS=""
+# FCQS_QUOTE_COMMANDS=false
+# FCQS_LIST_ALIASES=false
+# FCQS_SEPARATE_DUPLICATES=false
 
 FCQS_EDITOR=${FCQS_EDITOR:-default}
 FCQS_COPY_KEY=${FCQS_COPY_KEY:-ctrl-y}
+FCQS_COPY_BLOCK_KEY=${FCQS_COPY_BLOCK_KEY:-alt-y}
 FCQS_OPEN_KEY=${FCQS_OPEN_KEY:-ctrl-o}
 FCQS_EDIT_KEY=${FCQS_EDIT_KEY:-ctrl-e}
+FCQS_LINKS_KEY=${FCQS_LINKS_KEY:-alt-l}
+FCQS_BACKLINKS_KEY=${FCQS_BACKLINKS_KEY:-alt-b}
 FCQS_BASH_BIND_KEY=${FCQS_BASH_BIND_KEY:-"\C-o"}
-FCQS_COPY_COMMAND=${FCQS_COPY_COMMAND:-"xclip -selection c"}
+FCQS_COPY_COMMAND=${FCQS_COPY_COMMAND:-""}
 FCQS_COPY_WITH_TITLE=${FCQS_COPY_WITH_TITLE:-true}
 FCQS_OPEN_COMMAND=${FCQS_BROWSE_COMMAND:-"open"}
+FCQS_URL_MATCH=${FCQS_URL_MATCH:-""}
+FCQS_RELAXED_URLS=${FCQS_RELAXED_URLS:-false}
+FCQS_RESOLVE_SECRETS=${FCQS_RESOLVE_SECRETS:-false}
+FCQS_JOIN_COMMANDS=${FCQS_JOIN_COMMANDS:-""}
+FCQS_QUOTE_COMMANDS=${FCQS_QUOTE_COMMANDS:-false}
+FCQS_LIST_ALIASES=${FCQS_LIST_ALIASES:-false}
+FCQS_SEPARATE_DUPLICATES=${FCQS_SEPARATE_DUPLICATES:-false}
 
 FCQS_EDIT_COMMAND_DEFAULT="awk '{printf \"+%s %s\n\",\$2,\$1}' | xargs -o ${VISUAL} > /dev/tty"
 FCQS_EDIT_COMMAND_VSCODE="awk '{printf \"%s:%s\n\",\$1,\$2}' | xargs -o code -g"
@@ -26,17 +46,36 @@ FCQS_EDIT_COMMAND_VSCODE="awk '{printf \"%s:%s\n\",\$1,\$2}' | xargs -o code -g"
 
 [ "${FCQS_COPY_WITH_TITLE}" = true ] && FCQS_COPY_COMMAND_FLAG="" || FCQS_COPY_COMMAND_FLAG="-t"
 
+# fcqs-cli copies to the clipboard itself without FCQS_COPY_COMMAND.
+[ -n "${FCQS_COPY_COMMAND}" ] && FCQS_COPY_PIPE="| ${FCQS_COPY_COMMAND}" || FCQS_COPY_PIPE=""
+[ -n "${FCQS_COPY_COMMAND}" ] && FCQS_COPY_FLAG="" || FCQS_COPY_FLAG="--copy"
+
 fcqs() {
   local title
-  title=$(fcqs-cli |
-    fzf --preview "fcqs-cli {}" \
-      --bind "${FCQS_COPY_KEY}:execute-silent(fcqs-cli ${FCQS_COPY_COMMAND_FLAG} {} | ${FCQS_COPY_COMMAND}),${FCQS_OPEN_KEY}:execute-silent(fcqs-cli -u {} | xargs ${FCQS_OPEN_COMMAND}),${FCQS_EDIT_KEY}:execute-silent(fcqs-cli -l {} | ${FCQS_EDIT_COMMAND})+abort")
+  title=$(fcqs-cli --aliases="${FCQS_LIST_ALIASES}" --separate="${FCQS_SEPARATE_DUPLICATES}" |
+    FCQS_URL_MATCH="${FCQS_URL_MATCH}" fzf --preview "fcqs-cli --render {}" \
+      --bind "${FCQS_COPY_KEY}:execute-silent(fcqs-cli ${FCQS_COPY_FLAG} ${FCQS_COPY_COMMAND_FLAG} {} ${FCQS_COPY_PIPE}),${FCQS_OPEN_KEY}:execute(fcqs-cli --urls --relaxed-urls=${FCQS_RELAXED_URLS} {} | fzf --select-1 --exit-0 --prompt 'URL> ' | awk '{print \$NF}' | xargs ${FCQS_OPEN_COMMAND}),${FCQS_EDIT_KEY}:execute-silent(fcqs-cli -l {} | ${FCQS_EDIT_COMMAND})+abort" \
+      --bind "${FCQS_COPY_BLOCK_KEY}:execute(fcqs-cli --blocks {} | fzf --select-1 --exit-0 --prompt 'Block> ' | cut -d: -f1 | xargs -I % fcqs-cli ${FCQS_COPY_FLAG} --block % {} ${FCQS_COPY_PIPE})" \
+      --bind "${FCQS_LINKS_KEY}:reload(fcqs-cli --links {})+clear-query,${FCQS_BACKLINKS_KEY}:reload(fcqs-cli --backlinks {})+clear-query")
 
   if [ -n "$title" ]; then
     fcqs-cli "$title"
 
+    # Dangerous commands are pasted only after confirmation, and nothing is pasted if they cannot be checked.
+    local answer status
+    fcqs-cli --check "$title" >&2
+    status=$?
+    if [ "$status" -eq 2 ]; then
+      read -r -p "Paste the dangerous command? [y/N] " answer
+      [[ "$answer" =~ ^[yY] ]] || return
+    elif [ "$status" -ne 0 ]; then
+      return
+    fi
+
+    # Multi-line commands are pasted as one line with FCQS_QUOTE_COMMANDS=true to run them only by Enter.
+    # Secret references are pasted as shell expansions, so that the secrets are not in the history.
     local command
-    command=$(fcqs-cli -c "$title")
+    command=$(fcqs-cli -c --expand-secrets="${FCQS_RESOLVE_SECRETS}" --join="${FCQS_JOIN_COMMANDS}" --quote="${FCQS_QUOTE_COMMANDS}" "$title")
     READLINE_LINE="${READLINE_LINE:0:$READLINE_POINT}${command}${READLINE_LINE:$READLINE_POINT}"
     READLINE_POINT=$((READLINE_POINT + ${#command}))
   fi
@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -53,6 +54,11 @@ func TestCmdSuccess(t *testing.T) {
 			options: []string{"-u", "URL"},
 			stdout:  "http://github.com/yendo/fcqs/\n",
 		},
+		{
+			title:   "with urls flag and an arg",
+			options: []string{"--urls", "URL"},
+			stdout:  "fcqs: http://github.com/yendo/fcqs/\ngithub: http://github.com/\n",
+		},
 		{
 			title:   "with url flag and an empty arg",
 			options: []string{"-u", ""},
@@ -155,6 +161,16 @@ func TestCmdFail(t *testing.T) {
 			options: []string{"-l"},
 			stderr:  "invalid number of arguments\n",
 		},
+		{
+			title:   "with metadata flag and no arg",
+			options: []string{"-m"},
+			stderr:  "invalid number of arguments\n",
+		},
+		{
+			title:   "with invalid format",
+			options: []string{"--format", "xml"},
+			stderr:  "invalid format\n",
+		},
 		{
 			title:   "with two args",
 			options: []string{"title", "other"},
@@ -219,6 +235,25 @@ func TestCmdMultiFiles(t *testing.T) {
 		assert.Empty(t, cmd.stderr.String())
 	})
 
+	t.Run("show titles separately", func(t *testing.T) {
+		cmd := newTestCmd("-s")
+		err := cmd.run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "location test data [test_location.md]\n5th Line\n"+
+			"location test data [test_location_extra.md]\nother 5th Line\n9th Line\n", cmd.stdout.String())
+		assert.Empty(t, cmd.stderr.String())
+	})
+
+	t.Run("show location with locator", func(t *testing.T) {
+		cmd := newTestCmd("-l", "location test data [test_location_extra.md]")
+		err := cmd.run()
+
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("%q 1\n", LocationExtraFile), cmd.stdout.String())
+		assert.Empty(t, cmd.stderr.String())
+	})
+
 	t.Run("file error", func(t *testing.T) {
 		t.Setenv("FCQS_NOTES_FILE", MultiFiles(LocationFile, "invalid_file"))
 		t.Setenv("FCQS_NOTES_FILES", "")
@@ -232,6 +267,352 @@ func TestCmdMultiFiles(t *testing.T) {
 	})
 }
 
+func TestCmdSetextHeadings(t *testing.T) {
+	t.Setenv("FCQS_NOTES_FILE", SetextFile)
+	t.Setenv("FCQS_NOTES_FILES", "")
+
+	t.Run("show titles", func(t *testing.T) {
+		cmd := newTestCmd()
+		err := cmd.run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "Setext title\nSetext level 2 title\nATX title\n", cmd.stdout.String())
+		assert.Empty(t, cmd.stderr.String())
+	})
+
+	t.Run("show location", func(t *testing.T) {
+		cmd := newTestCmd("-l", "Setext level 2 title")
+		err := cmd.run()
+
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("%q 6\n", SetextFile), cmd.stdout.String())
+		assert.Empty(t, cmd.stderr.String())
+	})
+}
+
+func TestCmdFrontMatter(t *testing.T) {
+	t.Setenv("FCQS_NOTES_FILE", FrontMatterFile)
+	t.Setenv("FCQS_NOTES_FILES", "")
+
+	t.Run("show titles", func(t *testing.T) {
+		cmd := newTestCmd()
+		err := cmd.run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "k8s: pod logs\nk8s: events\n", cmd.stdout.String())
+		assert.Empty(t, cmd.stderr.String())
+	})
+
+	t.Run("show titles in JSON", func(t *testing.T) {
+		cmd := newTestCmd("--format", "json")
+		err := cmd.run()
+
+		require.NoError(t, err)
+		expected := fmt.Sprintf(`[
+			{"id": "75b5bf9f56ec", "title": "pod logs", "prefix": "k8s:", "file": %[1]q, "line": 8, "notebook": "work", "shell": "bash", "tags": ["k8s", "ops"]},
+			{"id": "92ac71a755cf", "title": "events", "prefix": "k8s:", "file": %[1]q, "line": 14, "notebook": "work", "shell": "bash", "tags": ["k8s", "ops"]}
+		]`, FrontMatterFile)
+		assert.JSONEq(t, expected, cmd.stdout.String())
+		assert.Empty(t, cmd.stderr.String())
+	})
+
+	t.Run("show command", func(t *testing.T) {
+		cmd := newTestCmd("-c", "k8s: pod logs")
+		err := cmd.run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "kubectl logs pod\n", cmd.stdout.String())
+		assert.Empty(t, cmd.stderr.String())
+	})
+}
+
+func TestCmdNoteMetadata(t *testing.T) {
+	t.Setenv("FCQS_NOTES_FILE", MetadataFile)
+	t.Setenv("FCQS_NOTES_FILES", "")
+
+	t.Run("show titles", func(t *testing.T) {
+		cmd := newTestCmd()
+		err := cmd.run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "events\npod logs\ncomment\nkubectl cheat sheet\n", cmd.stdout.String())
+		assert.Empty(t, cmd.stderr.String())
+	})
+
+	t.Run("show titles with aliases", func(t *testing.T) {
+		cmd := newTestCmd("-a")
+		err := cmd.run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "events\npod logs\nk8s logs\ncomment\nkubectl cheat sheet\nkubectl\n", cmd.stdout.String())
+		assert.Empty(t, cmd.stderr.String())
+	})
+
+	t.Run("show contents by alias", func(t *testing.T) {
+		cmd := newTestCmd("k8s logs")
+		err := cmd.run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "# pod logs\n\nkubectl logs\n", cmd.stdout.String())
+		assert.Empty(t, cmd.stderr.String())
+	})
+
+	t.Run("show contents", func(t *testing.T) {
+		cmd := newTestCmd("pod logs")
+		err := cmd.run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "# pod logs\n\nkubectl logs\n", cmd.stdout.String())
+		assert.Empty(t, cmd.stderr.String())
+	})
+
+	t.Run("show contents with metadata", func(t *testing.T) {
+		cmd := newTestCmd("--with-metadata", "events")
+		err := cmd.run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "# events\n\n<!-- pinned: true -->\n\nkubectl get events\n", cmd.stdout.String())
+		assert.Empty(t, cmd.stderr.String())
+	})
+
+	t.Run("show metadata", func(t *testing.T) {
+		cmd := newTestCmd("-m", "events")
+		err := cmd.run()
+
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("id: 3d21a5e60aaa\ntitle: events\nfile: %s\nline: 16\ntags: ops\npinned: true\n", MetadataFile), cmd.stdout.String())
+		assert.Empty(t, cmd.stderr.String())
+	})
+}
+
+func TestCmdInvalidMetadata(t *testing.T) {
+	t.Setenv("FCQS_NOTES_FILE", InvalidMetadataFile)
+	t.Setenv("FCQS_NOTES_FILES", "")
+
+	cmd := newTestCmd("-m", "not metadata")
+	err := cmd.run()
+
+	require.NoError(t, err)
+	assert.Contains(t, cmd.stdout.String(), "title: not metadata\n")
+	assert.Equal(t, fmt.Sprintf("warning: %[1]s:2: invalid metadata: env: no value: GREETING\n"+
+		"warning: %[1]s:11: invalid metadata: pinned: not a boolean: yes please\n"+
+		"warning: %[1]s:12: invalid metadata: unknown key: author\n", InvalidMetadataFile), cmd.stderr.String())
+}
+
+func TestCmdNoteID(t *testing.T) {
+	t.Setenv("FCQS_NOTES_FILE", IDFile)
+	t.Setenv("FCQS_NOTES_FILES", "")
+
+	t.Run("show contents by ID", func(t *testing.T) {
+		cmd := newTestCmd("--id", "k8s-rollout", "-t")
+		err := cmd.run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "kubectl rollout status deployment\n", cmd.stdout.String())
+		assert.Empty(t, cmd.stderr.String())
+	})
+
+	t.Run("show metadata by ID", func(t *testing.T) {
+		cmd := newTestCmd("-m", "--id", "bc177c27c4b6")
+		err := cmd.run()
+
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("id: bc177c27c4b6\ntitle: rollout history\nfile: %s\nline: 5\n", IDFile), cmd.stdout.String())
+		assert.Empty(t, cmd.stderr.String())
+	})
+}
+
+func TestCmdLinks(t *testing.T) {
+	t.Setenv("FCQS_NOTES_FILE", LinksFile)
+	t.Setenv("FCQS_NOTES_FILES", "")
+
+	t.Run("show links", func(t *testing.T) {
+		cmd := newTestCmd("--links", "deploy")
+		err := cmd.run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "build image\nrollout status\n", cmd.stdout.String())
+		assert.Equal(t, fmt.Sprintf("warning: %[1]s:4: broken link: Build Image\n"+
+			"warning: %[1]s:10: broken link: missing note\n", LinksFile), cmd.stderr.String())
+	})
+
+	t.Run("show backlinks", func(t *testing.T) {
+		cmd := newTestCmd("--backlinks", "rollout")
+		err := cmd.run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "deploy\n", cmd.stdout.String())
+		assert.Empty(t, cmd.stderr.String())
+	})
+}
+
+func TestCmdTransclusion(t *testing.T) {
+	t.Setenv("FCQS_NOTES_FILE", TransclusionFile)
+	t.Setenv("FCQS_NOTES_FILES", "")
+
+	t.Run("show contents", func(t *testing.T) {
+		cmd := newTestCmd("-t", "auth login")
+		err := cmd.run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "Connect to the VPN.\n\n```sh\nvpn connect\n```\n\n```sh\nauth login\n```\n", cmd.stdout.String())
+		assert.Empty(t, cmd.stderr.String())
+	})
+
+	t.Run("show contents with cycle", func(t *testing.T) {
+		cmd := newTestCmd("cycle b")
+		err := cmd.run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "# cycle b\n\n![[cycle b]]\n", cmd.stdout.String())
+		assert.Equal(t, fmt.Sprintf("warning: %s:34: transclusion cycle: cycle b\n", TransclusionFile), cmd.stderr.String())
+	})
+}
+
+func TestCmdInclude(t *testing.T) {
+	t.Setenv("FCQS_NOTES_FILE", IncludeFile)
+	t.Setenv("FCQS_NOTES_FILES", "")
+
+	t.Run("show contents", func(t *testing.T) {
+		cmd := newTestCmd("-c", "k8s: pods")
+		err := cmd.run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "kubectl get pods\n", cmd.stdout.String())
+		assert.Contains(t, cmd.stderr.String(), "include cycle")
+	})
+
+	t.Run("show location", func(t *testing.T) {
+		cmd := newTestCmd("-l", "shared")
+		err := cmd.run()
+
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("%q 1\n", IncludedShared), cmd.stdout.String())
+	})
+}
+
+func TestCmdRender(t *testing.T) {
+	t.Setenv("FCQS_NOTES_FILE", NotesFile)
+	t.Setenv("FCQS_NOTES_FILES", "")
+	t.Setenv("NO_COLOR", "1")
+	t.Setenv("FZF_PREVIEW_COLUMNS", "40")
+
+	cmd := newTestCmd("--render", "-t", "command-line")
+	err := cmd.run()
+
+	require.NoError(t, err)
+	assert.Equal(t, "┌─ sh ───────┐\n│ ls -l | nl │\n└────────────┘\n", cmd.stdout.String())
+	assert.Empty(t, cmd.stderr.String())
+}
+
+func TestCmdBlocks(t *testing.T) {
+	t.Setenv("FCQS_NOTES_FILE", BlocksFile)
+	t.Setenv("FCQS_NOTES_FILES", "")
+
+	t.Run("show block", func(t *testing.T) {
+		cmd := newTestCmd("--block", "2", "--lang", "yaml", "deployment")
+		err := cmd.run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "\nreplicas: 3\n", cmd.stdout.String())
+	})
+
+	t.Run("list blocks", func(t *testing.T) {
+		cmd := newTestCmd("--blocks", "deployment")
+		err := cmd.run()
+
+		require.NoError(t, err)
+		assert.Equal(t, "1: [sh] kubectl apply -f deployment.yaml\n2: [yaml] apiVersion: apps/v1\n3: [YAML] replicas: 3\n4: no language\n",
+			cmd.stdout.String())
+	})
+}
+
+func TestCmdJoinCommands(t *testing.T) {
+	t.Setenv("FCQS_NOTES_FILE", SessionFile)
+	t.Setenv("FCQS_NOTES_FILES", "")
+
+	cmd := newTestCmd("-c", "--join", "&&", "join commands")
+	err := cmd.run()
+
+	require.NoError(t, err)
+	assert.Equal(t, "cd src && make install && if [ -f done ]; then echo done; fi && for f in *.txt; do cat \"$f\" | wc -l; done\n",
+		cmd.stdout.String())
+}
+
+func TestCmdQuoteCommands(t *testing.T) {
+	t.Setenv("FCQS_NOTES_FILE", SessionFile)
+	t.Setenv("FCQS_NOTES_FILES", "")
+
+	cmd := newTestCmd("-c", "--quote", "indented here document")
+	err := cmd.run()
+	require.NoError(t, err)
+
+	out, err := exec.Command("bash", "-c", cmd.stdout.String()).Output()
+
+	require.NoError(t, err)
+	assert.Equal(t, "  it's indented\n", string(out))
+}
+
+func TestCmdRun(t *testing.T) {
+	t.Setenv("FCQS_NOTES_FILE", RunFile)
+	t.Setenv("FCQS_NOTES_FILES", "")
+	t.Setenv("FCQS_HISTORY_FILE", filepath.Join(t.TempDir(), "history.jsonl"))
+	t.Setenv("SHELL", "/bin/sh")
+
+	t.Run("exit status of command", func(t *testing.T) {
+		cmd := newTestCmd("--run", "fail")
+		cmd.cmd.Stdin = strings.NewReader("y\n")
+		err := cmd.run()
+
+		var exitErr *exec.ExitError
+		require.ErrorAs(t, err, &exitErr)
+		assert.Equal(t, 3, exitErr.ExitCode())
+		assert.Equal(t, "exit 3\nRun the command? [y/N] ", cmd.stdout.String())
+		assert.Empty(t, cmd.stderr.String())
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		cmd := newTestCmd("--run", "fail")
+		cmd.cmd.Stdin = strings.NewReader("n\n")
+		err := cmd.run()
+
+		var exitErr *exec.ExitError
+		require.ErrorAs(t, err, &exitErr)
+		assert.Equal(t, 1, exitErr.ExitCode())
+		assert.Equal(t, "canceled\n", cmd.stderr.String())
+	})
+}
+
+func TestCmdLintSecrets(t *testing.T) {
+	t.Setenv("FCQS_NOTES_FILE", SecretsFile)
+	t.Setenv("FCQS_NOTES_FILES", "")
+
+	cmd := newTestCmd("lint", "--secrets")
+	err := cmd.run()
+
+	var exitErr *exec.ExitError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, 1, exitErr.ExitCode())
+	assert.Equal(t, fmt.Sprintf("%[1]s:4: possible secret: AWS access key ID\n"+
+		"%[1]s:5: possible secret: AWS secret access key\n"+
+		"%[1]s:12: possible secret: GitHub token\n"+
+		"%[1]s:18: possible secret: private key\n"+
+		"%[1]s:24: possible secret: high-entropy string\n", SecretsFile), cmd.stdout.String())
+	assert.Empty(t, cmd.stderr.String())
+}
+
+func TestCmdUnterminatedFence(t *testing.T) {
+	t.Setenv("FCQS_NOTES_FILE", MultiFiles(UnterminatedFile, LocationFile))
+	t.Setenv("FCQS_NOTES_FILES", "")
+
+	cmd := newTestCmd()
+	err := cmd.run()
+
+	require.NoError(t, err)
+	assert.Equal(t, "unterminated fence\nlocation test data\n5th Line\n", cmd.stdout.String())
+	assert.Equal(t, fmt.Sprintf("warning: %s:3: unterminated fenced code block\n", UnterminatedFile), cmd.stderr.String())
+}
+
 func TestUserHomeDirNotExists(t *testing.T) {
 	t.Setenv("FCQS_NOTES_FILE", "")
 	t.Setenv("FCQS_NOTES_FILES", "")
@@ -9,17 +9,49 @@ import (
 )
 
 const (
-	notesFile         = "testdata/test_fcnotes.md"
-	shellBlockFile    = "testdata/test_shellblock.md"
-	locationFile      = "testdata/test_location.md"
-	locationExtraFile = "testdata/test_location_extra.md"
+	notesFile           = "testdata/test_fcnotes.md"
+	shellBlockFile      = "testdata/test_shellblock.md"
+	locationFile        = "testdata/test_location.md"
+	locationExtraFile   = "testdata/test_location_extra.md"
+	unterminatedFile    = "testdata/test_unterminated.md"
+	setextFile          = "testdata/test_setext.md"
+	frontMatterFile     = "testdata/test_frontmatter.md"
+	metadataFile        = "testdata/test_metadata.md"
+	invalidMetadataFile = "testdata/test_invalid_metadata.md"
+	idFile              = "testdata/test_id.md"
+	linksFile           = "testdata/test_links.md"
+	transclusionFile    = "testdata/test_transclusion.md"
+	includeFile         = "testdata/test_include.md"
+	includedFile        = "testdata/include/k8s.md"
+	includedShared      = "testdata/include/shared.md"
+	urlsFile            = "testdata/test_urls.md"
+	blocksFile          = "testdata/test_blocks.md"
+	runFile             = "testdata/test_run.md"
+	secretsFile         = "testdata/test_secrets.md"
+	sessionFile         = "testdata/test_session.md"
 )
 
 var (
-	NotesFile         = fullPath(notesFile)
-	ShellBlockFile    = fullPath(shellBlockFile)
-	LocationFile      = fullPath(locationFile)
-	LocationExtraFile = fullPath(locationExtraFile)
+	NotesFile           = fullPath(notesFile)
+	ShellBlockFile      = fullPath(shellBlockFile)
+	LocationFile        = fullPath(locationFile)
+	LocationExtraFile   = fullPath(locationExtraFile)
+	UnterminatedFile    = fullPath(unterminatedFile)
+	SetextFile          = fullPath(setextFile)
+	FrontMatterFile     = fullPath(frontMatterFile)
+	MetadataFile        = fullPath(metadataFile)
+	InvalidMetadataFile = fullPath(invalidMetadataFile)
+	IDFile              = fullPath(idFile)
+	LinksFile           = fullPath(linksFile)
+	TransclusionFile    = fullPath(transclusionFile)
+	IncludeFile         = fullPath(includeFile)
+	IncludedFile        = fullPath(includedFile)
+	IncludedShared      = fullPath(includedShared)
+	URLsFile            = fullPath(urlsFile)
+	BlocksFile          = fullPath(blocksFile)
+	RunFile             = fullPath(runFile)
+	SecretsFile         = fullPath(secretsFile)
+	SessionFile         = fullPath(sessionFile)
 )
 
 // MultiFiles returns file names concatenated with PathListSeparator for FCQS_NOTES_FILE.
@@ -0,0 +1,73 @@
+package fcqs
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+
+	"github.com/yendo/fcqs/internal/value"
+)
+
+// maxTransclusionDepth is the maximum depth of nested transclusions.
+const maxTransclusionDepth = 8
+
+// transclusionPattern matches the line of the transclusion directive like "![[title]]".
+var transclusionPattern = regexp.MustCompile(`^\s*!\[\[([^\[\]]+)\]\]\s*$`)
+
+// transclusion expands the transclusion directives with the contents of the notes.
+type transclusion struct {
+	notes  []*noteContents
+	policy value.MatchPolicy
+}
+
+// write writes the lines expanding the transclusion directives.
+// stack is the notes being expanded to detect cycles.
+// A directive that cannot be expanded is written as it is with a warning.
+func (t *transclusion) write(w io.Writer, lines []line, stack []*noteContents) {
+	for _, line := range lines {
+		title, ok := t.directive(line)
+		if !ok {
+			fmt.Fprint(w, line.text)
+			continue
+		}
+
+		targets := slices.DeleteFunc(slices.Clone(t.notes), func(n *noteContents) bool { return !n.hasTitle(title) })
+
+		switch {
+		case len(targets) == 0:
+			fmt.Fprintf(warnWriter, "warning: %s: transcluded note not found: %s\n", line.location(), title)
+		case slices.ContainsFunc(targets, func(n *noteContents) bool { return slices.Contains(stack, n) }):
+			fmt.Fprintf(warnWriter, "warning: %s: transclusion cycle: %s\n", line.location(), title)
+		case len(stack) > maxTransclusionDepth:
+			fmt.Fprintf(warnWriter, "warning: %s: transclusion too deep: %s\n", line.location(), title)
+		default:
+			for _, n := range targets {
+				t.write(w, n.body(), append(slices.Clone(stack), n))
+			}
+			continue
+		}
+
+		fmt.Fprint(w, line.text)
+	}
+}
+
+// directive returns the title of the transclusion directive in the line.
+// Directives in fenced code blocks are ignored.
+func (t *transclusion) directive(l line) (*value.Title, bool) {
+	if l.kind != textLine {
+		return nil, false
+	}
+
+	m := transclusionPattern.FindStringSubmatch(l.text)
+	if m == nil {
+		return nil, false
+	}
+
+	title, err := value.NewTitle(m[1])
+	if err != nil {
+		return nil, false
+	}
+
+	return title.WithMatchPolicy(t.policy), true
+}
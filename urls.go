@@ -0,0 +1,185 @@
+package fcqs
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/yendo/fcqs/internal/value"
+	"mvdan.cc/xurls/v2"
+)
+
+const (
+	// markdownLinkStart and markdownLinkEnd surround the text of a markdown link like "[text](url)".
+	markdownLinkStart = "["
+	markdownLinkEnd   = "]("
+
+	labelSeparator = ":"
+
+	// defaultScheme is the scheme of relaxed URLs without schemes.
+	defaultScheme = "https://"
+	mailScheme    = "mailto:"
+)
+
+// listMarkers are the markers of list items before labels.
+var listMarkers = []string{"- ", "* ", "+ "}
+
+// URLOptions represents options to find URLs in the note.
+type URLOptions struct {
+	// Match is the pattern to choose URLs by their schemes, domains or labels.
+	Match string
+
+	// Relaxed finds URLs without schemes like "github.com/foo".
+	Relaxed bool
+}
+
+// noteURL represents a URL in a note with its label.
+type noteURL struct {
+	url   string
+	label string
+}
+
+// String returns the URL with the label like "label: url".
+func (u noteURL) String() string {
+	if u.label == "" {
+		return u.url
+	}
+
+	return u.label + labelSeparator + " " + u.url
+}
+
+// matches reports whether the scheme, the domain or the label of the URL matches the pattern.
+// The domain matches its subdomains, and the label matches a part of it ignoring cases.
+func (u noteURL) matches(pattern string) bool {
+	if pattern == "" {
+		return true
+	}
+
+	if parsed, err := url.Parse(u.url); err == nil {
+		scheme := strings.TrimSuffix(strings.TrimSuffix(pattern, "://"), ":")
+		host := strings.ToLower(parsed.Hostname())
+		domain := strings.ToLower(pattern)
+
+		if strings.EqualFold(parsed.Scheme, scheme) || host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+
+	return strings.Contains(strings.ToLower(u.label), strings.ToLower(pattern))
+}
+
+// WriteFirstURL writes the first URL in the contents of the note.
+func WriteFirstURL(w io.Writer, r io.Reader, ref value.NoteRef) error {
+	return WriteFirstURLWithOptions(w, r, ref, URLOptions{})
+}
+
+// WriteFirstURLWithOptions writes the first URL in the contents of the note with the options.
+func WriteFirstURLWithOptions(w io.Writer, r io.Reader, ref value.NoteRef, opts URLOptions) error {
+	urls, err := seekURLs(r, ref, opts)
+	if err != nil {
+		return err
+	}
+
+	if len(urls) > 0 {
+		fmt.Fprintln(w, urls[0].url)
+	}
+
+	return nil
+}
+
+// WriteURLs writes all URLs in the contents of the note with their labels.
+func WriteURLs(w io.Writer, r io.Reader, ref value.NoteRef) error {
+	return WriteURLsWithOptions(w, r, ref, URLOptions{})
+}
+
+// WriteURLsWithOptions writes all URLs in the contents of the note with their labels and the options.
+func WriteURLsWithOptions(w io.Writer, r io.Reader, ref value.NoteRef, opts URLOptions) error {
+	urls, err := seekURLs(r, ref, opts)
+	if err != nil {
+		return err
+	}
+
+	for _, u := range urls {
+		fmt.Fprintln(w, u)
+	}
+
+	return nil
+}
+
+// seekURLs returns the URLs matching the options in the contents of the note without duplicates.
+func seekURLs(r io.Reader, ref value.NoteRef, opts URLOptions) ([]noteURL, error) {
+	var buf bytes.Buffer
+	if err := WriteContents(&buf, r, ref, false); err != nil {
+		return nil, err
+	}
+
+	var urls []noteURL
+
+	rx := xurls.Strict()
+	if opts.Relaxed {
+		rx = xurls.Relaxed()
+	}
+
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		start := 0
+		for _, loc := range rx.FindAllStringIndex(line, -1) {
+			u := noteURL{url: withScheme(line[loc[0]:loc[1]]), label: urlLabel(line[start:loc[0]])}
+			start = loc[1]
+
+			if !u.matches(opts.Match) || slices.ContainsFunc(urls, func(v noteURL) bool { return v.url == u.url }) {
+				continue
+			}
+			urls = append(urls, u)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("seek URLs: %w", err)
+	}
+
+	return urls, nil
+}
+
+// strictURLPattern matches the whole URL with a scheme.
+var strictURLPattern = regexp.MustCompile(`^(?:` + xurls.Strict().String() + `)$`)
+
+// withScheme returns the URL with the scheme for the relaxed URL without it.
+func withScheme(u string) string {
+	switch {
+	case strictURLPattern.MatchString(u):
+		return u
+	case strings.Contains(u, "@") && !strings.Contains(u, "/"):
+		return mailScheme + u
+	default:
+		return defaultScheme + u
+	}
+}
+
+// urlLabel returns the label in the text before the URL.
+// The label is the text of the markdown link like "[label](url)", or the text like "label: url".
+func urlLabel(prefix string) string {
+	if text, ok := strings.CutSuffix(prefix, markdownLinkEnd); ok {
+		if i := strings.LastIndex(text, markdownLinkStart); i >= 0 {
+			return strings.TrimSpace(text[i+len(markdownLinkStart):])
+		}
+	}
+
+	label, ok := strings.CutSuffix(strings.TrimRight(prefix, " <"), labelSeparator)
+	if !ok {
+		return ""
+	}
+
+	label = strings.TrimLeft(label, " ")
+	for _, marker := range listMarkers {
+		label = strings.TrimPrefix(label, marker)
+	}
+
+	return strings.TrimSpace(label)
+}